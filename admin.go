@@ -0,0 +1,236 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mymmrac/memkey"
+	"github.com/valyala/fasthttp"
+
+	"github.com/mymmrac/syodo-telegram-bot/logger"
+)
+
+const (
+	authSchemeBearer = "Bearer "
+	authSchemeBasic  = "Basic "
+
+	adminRealm = `Basic realm="admin"`
+)
+
+// orderSummary represents order info available for inspection without personal data
+type orderSummary struct {
+	OrderID         string    `json:"orderID"`
+	ExternalOrderID string    `json:"externalOrderID,omitempty"`
+	DeliveryType    string    `json:"deliveryType"`
+	PaymentMethod   string    `json:"paymentMethod"`
+	ProductCount    int       `json:"productCount"`
+	TotalAmount     float64   `json:"totalAmount"`
+	ScheduledAt     time.Time `json:"scheduledAt,omitempty"`
+	CreatedAt       time.Time `json:"createdAt"`
+	CorrelationID   string    `json:"correlationID"`
+}
+
+// orderDetailsResponse represents order info available for inspection of single order, personal data is masked and
+// web app data is omitted, so it can't be used to send orders on behalf of user
+type orderDetailsResponse struct {
+	orderSummary
+	OrderURL          string         `json:"orderURL,omitempty"`
+	ServiceArea       string         `json:"serviceArea,omitempty"`
+	PromoCodeDiscount int            `json:"promoCodeDiscount,omitempty"`
+	Products          []OrderProduct `json:"products"`
+	DoNotCall         bool           `json:"doNotCall"`
+	NoNapkins         bool           `json:"noNapkins"`
+	CutleryCount      int            `json:"cutleryCount"`
+	TrainingCutlery   int            `json:"trainingCutleryCount"`
+	Promotion         string         `json:"promotion,omitempty"`
+	PromoCode         string         `json:"promoCode,omitempty"`
+	DeliveryDate      string         `json:"deliveryDate,omitempty"`
+	DeliveryTime      string         `json:"deliveryTime,omitempty"`
+	ChangeFrom        int            `json:"changeFrom,omitempty"`
+	City              string         `json:"city,omitempty"`
+	Name              string         `json:"name"`
+	Phone             string         `json:"phone"`
+	Address           string         `json:"address,omitempty"`
+	Comment           string         `json:"comment,omitempty"`
+}
+
+// ordersResponse represents response of order inspection endpoint
+type ordersResponse struct {
+	Count  int            `json:"count"`
+	Orders []orderSummary `json:"orders"`
+}
+
+// registerAdminHandlers registers ops endpoints on admin server, all of them except liveness check require
+// authorization, so liveness probes don't need admin token
+func (h *Handler) registerAdminHandlers() {
+	h.adminRtr.GET("/metrics", h.adminAuth(h.metrics.Handler()))
+	h.adminRtr.GET("/healthz", h.healthz)
+	h.adminRtr.GET("/readyz", h.adminAuth(h.requestCorrelation(h.readyz)))
+	h.adminRtr.GET("/orders", h.adminAuth(h.ordersHandler))
+	h.adminRtr.GET("/orders/{orderID}", h.adminAuth(h.orderDetailsHandler))
+	h.adminRtr.POST("/reload", h.adminAuth(h.requestCorrelation(h.reloadHandler)))
+}
+
+// adminAuth allows only requests authorized by admin token passed as bearer token or as password of basic auth
+func (h *Handler) adminAuth(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+	return func(ctx *fasthttp.RequestCtx) {
+		if !h.isAdminAuthorized(string(ctx.Request.Header.Peek(fasthttp.HeaderAuthorization))) {
+			h.log.Warnf("Unauthorized admin request %s %s from %s", ctx.Method(), ctx.Path(), ctx.RemoteIP())
+			ctx.Response.Header.Set(fasthttp.HeaderWWWAuthenticate, adminRealm)
+			ctx.SetStatusCode(fasthttp.StatusUnauthorized)
+			return
+		}
+
+		next(ctx)
+	}
+}
+
+func (h *Handler) isAdminAuthorized(authorization string) bool {
+	var token string
+	switch {
+	case strings.HasPrefix(authorization, authSchemeBearer):
+		token = strings.TrimPrefix(authorization, authSchemeBearer)
+	case strings.HasPrefix(authorization, authSchemeBasic):
+		credentials, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(authorization, authSchemeBasic))
+		if err != nil {
+			return false
+		}
+
+		_, password, ok := strings.Cut(string(credentials), ":")
+		if !ok {
+			return false
+		}
+		token = password
+	default:
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(token), []byte(h.config().App.AdminToken)) == 1
+}
+
+// ordersHandler lists stored orders without personal data, newest first
+func (h *Handler) ordersHandler(ctx *fasthttp.RequestCtx) {
+	entries := memkey.Entries[OrderDetails](h.orderStore)
+
+	resp := ordersResponse{
+		Orders: make([]orderSummary, 0, len(entries)),
+	}
+	for _, e := range entries {
+		resp.Orders = append(resp.Orders, newOrderSummary(e.Value))
+	}
+	resp.Count = len(resp.Orders)
+
+	sort.Slice(resp.Orders, func(i, j int) bool {
+		return resp.Orders[i].CreatedAt.After(resp.Orders[j].CreatedAt)
+	})
+
+	h.writeJSON(ctx, resp)
+}
+
+// newOrderSummary returns order info without personal data
+func newOrderSummary(order OrderDetails) orderSummary {
+	return orderSummary{
+		OrderID:         order.OrderID,
+		ExternalOrderID: order.ExternalOrderID,
+		DeliveryType:    order.Request.DeliveryType,
+		PaymentMethod:   order.Request.PaymentMethod,
+		ProductCount:    len(order.Request.Products),
+		TotalAmount:     order.TotalAmount,
+		ScheduledAt:     order.ScheduledAt,
+		CreatedAt:       order.CreatedAt,
+		CorrelationID:   order.CorrelationID,
+	}
+}
+
+// newOrderDetailsResponse returns order info with masked personal data and without web app data
+func newOrderDetailsResponse(order OrderDetails) orderDetailsResponse {
+	request := order.Request
+	address := strings.Join(nonEmpty(request.Address, request.Entrance, request.Floor, request.Apartment,
+		request.ECode), ", ")
+
+	return orderDetailsResponse{
+		orderSummary:      newOrderSummary(order),
+		OrderURL:          order.OrderURL,
+		ServiceArea:       order.ServiceArea,
+		PromoCodeDiscount: order.PromoCodeDiscount,
+		Products:          request.Products,
+		DoNotCall:         request.DoNotCall,
+		NoNapkins:         request.NoNapkins,
+		CutleryCount:      request.CutleryCount,
+		TrainingCutlery:   request.TrainingCutleryCount,
+		Promotion:         request.Promotion,
+		PromoCode:         request.PromoCode,
+		DeliveryDate:      request.DeliveryDate,
+		DeliveryTime:      request.DeliveryTime,
+		ChangeFrom:        request.ChangeFrom,
+		City:              request.City,
+		Name:              maskText(request.Name),
+		Phone:             logger.MaskPhone(request.Phone),
+		Address:           maskText(address),
+		Comment:           maskText(request.Comment),
+	}
+}
+
+// maskText hides all characters of text except the first one, empty text is kept empty
+func maskText(text string) string {
+	runes := []rune(text)
+	if len(runes) == 0 {
+		return ""
+	}
+
+	return string(runes[0]) + strings.Repeat("*", len(runes)-1)
+}
+
+// nonEmpty returns non-empty values
+func nonEmpty(values ...string) []string {
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		if value != "" {
+			result = append(result, value)
+		}
+	}
+
+	return result
+}
+
+// orderDetailsHandler returns details of stored order with masked personal data
+func (h *Handler) orderDetailsHandler(ctx *fasthttp.RequestCtx) {
+	orderID, _ := ctx.UserValue("orderID").(string)
+
+	order, ok := h.getOrder(orderID)
+	if !ok {
+		ctx.SetStatusCode(fasthttp.StatusNotFound)
+		return
+	}
+
+	h.writeJSON(ctx, newOrderDetailsResponse(order))
+}
+
+// reloadHandler reloads text data the same way as reload command does
+func (h *Handler) reloadHandler(ctx *fasthttp.RequestCtx) {
+	log := h.logFor(ctx)
+
+	if err := h.Reload(); err != nil {
+		log.Errorf("Reload by admin request: %s", err)
+		ctx.SetStatusCode(fasthttp.StatusInternalServerError)
+		//nolint:errcheck
+		_, _ = ctx.WriteString(err.Error())
+		return
+	}
+
+	log.Info("Reloaded by admin request")
+	ctx.SetStatusCode(fasthttp.StatusOK)
+}
+
+func (h *Handler) writeJSON(ctx *fasthttp.RequestCtx, resp any) {
+	ctx.SetStatusCode(fasthttp.StatusOK)
+	ctx.SetContentType(contentTypeJSON)
+
+	if err := json.NewEncoder(ctx).Encode(resp); err != nil {
+		h.logFor(ctx).Errorf("Write response: %s", err)
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/mymmrac/syodo-telegram-bot/config"
+)
+
+func TestIsAdminAuthorized(t *testing.T) {
+	const token = "0123456789abcdef"
+	h := &Handler{}
+	h.cfg.Store(&config.Config{App: config.App{AdminToken: token}})
+
+	basic := func(credentials string) string {
+		return "Basic " + base64.StdEncoding.EncodeToString([]byte(credentials))
+	}
+
+	tests := []struct {
+		authorization string
+		authorized    bool
+	}{
+		{authorization: "Bearer " + token, authorized: true},
+		{authorization: basic("prometheus:" + token), authorized: true},
+		{authorization: "", authorized: false},
+		{authorization: "Bearer wrong", authorized: false},
+		{authorization: token, authorized: false},
+		{authorization: basic(token), authorized: false},
+		{authorization: basic("admin:wrong"), authorized: false},
+		{authorization: "Basic !!!", authorized: false},
+	}
+
+	for _, tt := range tests {
+		if got := h.isAdminAuthorized(tt.authorization); got != tt.authorized {
+			t.Errorf("authorization %q: expected %t, got %t", tt.authorization, tt.authorized, got)
+		}
+	}
+}
+
+func TestNewOrderDetailsResponse(t *testing.T) {
+	order := OrderDetails{
+		OrderID: "000001",
+		Request: OrderRequest{
+			AppData:  "query_id=AAF&user=%7B%22id%22%3A1%7D&auth_date=1679140800&hash=abc",
+			Products: []OrderProduct{{ID: "1", Title: "Філадельфія", Price: 29900, Amount: 2}},
+			Comment:  "Ring twice",
+			Name:     "Taras",
+			Phone:    "+380671234567",
+			City:     "Lviv",
+			Address:  "Shevchenka 1",
+			Floor:    "3",
+		},
+	}
+
+	data, err := json.Marshal(newOrderDetailsResponse(order))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp := string(data)
+
+	for _, personal := range []string{"auth_date", "hash", "Taras", "671234567", "Shevchenka", "Ring"} {
+		if strings.Contains(resp, personal) {
+			t.Errorf("unexpected %q in response: %s", personal, resp)
+		}
+	}
+
+	for _, expected := range []string{`"orderID":"000001"`, `"name":"T****"`, `"phone":"+38067*****67"`,
+		`"city":"Lviv"`, "Філадельфія"} {
+		if !strings.Contains(resp, expected) {
+			t.Errorf("expected %q in response: %s", expected, resp)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/BurntSushi/toml"
+	"github.com/go-playground/validator/v10"
+)
+
+// Category represents product category metadata
+type Category struct {
+	ID    string `validate:"required"`
+	Name  string `validate:"required"`
+	Emoji string `validate:"required"`
+}
+
+// Catalog represents product categories metadata
+type Catalog struct {
+	defaultEmoji string
+	categories   map[string]Category
+}
+
+// LoadCatalog loads categories from specified file
+func LoadCatalog(filename string) (*Catalog, error) {
+	var catalogFile struct {
+		DefaultEmoji string     `validate:"required"`
+		Category     []Category `validate:"dive"`
+	}
+
+	_, err := toml.DecodeFile(filename, &catalogFile)
+	if err != nil {
+		return nil, fmt.Errorf("decode catalog: %w", err)
+	}
+
+	if err = validator.New().Struct(catalogFile); err != nil {
+		return nil, fmt.Errorf("catalog validation: %w", err)
+	}
+
+	catalog := &Catalog{
+		defaultEmoji: catalogFile.DefaultEmoji,
+		categories:   make(map[string]Category, len(catalogFile.Category)),
+	}
+
+	for _, category := range catalogFile.Category {
+		if _, ok := catalog.categories[category.ID]; ok {
+			return nil, fmt.Errorf("duplicate category %q", category.ID)
+		}
+
+		catalog.categories[category.ID] = category
+	}
+
+	return catalog, nil
+}
+
+// Emoji returns emoji of category, default emoji is returned for unknown categories
+func (c *Catalog) Emoji(categoryID string) string {
+	if category, ok := c.categories[categoryID]; ok {
+		return category.Emoji
+	}
+	return c.defaultEmoji
+}
@@ -1,53 +1,177 @@
 package main
 
 import (
+	"strings"
+	"time"
+
+	"github.com/mymmrac/memkey"
 	"github.com/mymmrac/telego"
 	tu "github.com/mymmrac/telego/telegoutil"
 )
 
-func (h *Handler) startCmd(bot *telego.Bot, message telego.Message) {
+// ClosedInfo represents info about closure displayed to user
+type ClosedInfo struct {
+	Reason      string
+	NextOpening time.Time
+}
+
+func (h *Handler) startCmd(bot *telego.Bot, update telego.Update) {
+	ctx := update.Context()
+	message := *update.Message
+	log := h.logFor(ctx)
 	chatID := message.Chat.ID
+	locale := h.userLocale(message.From)
+
+	text := h.temp(ctx, locale, "start", message)
+	if now := h.now(); !h.workingHours().IsOpen(now) {
+		reason, _ := h.workingHours().Closure()
+		text += "\n\n" + h.temp(ctx, locale, "closed", ClosedInfo{
+			Reason:      reason,
+			NextOpening: h.workingHours().NextOpening(now),
+		})
+	}
+
 	_, err := bot.SendMessage(
-		tu.Message(tu.ID(chatID), h.data.Temp("start", message)).
+		tu.Message(tu.ID(chatID), text).
 			WithParseMode(telego.ModeHTML).
 			WithReplyMarkup(tu.InlineKeyboard(
 				tu.InlineKeyboardRow(
-					tu.InlineKeyboardButton(h.data.Text("menuButton")).
-						WithWebApp(&telego.WebAppInfo{URL: h.cfg.App.WebAppURL}),
+					tu.InlineKeyboardButton(h.text(ctx, locale, "menuButton")).
+						WithWebApp(&telego.WebAppInfo{URL: h.config().App.WebAppURL}),
 				),
 			)),
 	)
 	if err != nil {
-		h.log.Errorf("Send start message: %s", err)
+		log.Errorf("Send start message: %s", err)
 	}
 }
 
-func (h *Handler) helpCmd(bot *telego.Bot, message telego.Message) {
+func (h *Handler) helpCmd(bot *telego.Bot, update telego.Update) {
+	ctx := update.Context()
+	message := *update.Message
+	log := h.logFor(ctx)
 	chatID := message.Chat.ID
+	locale := h.userLocale(message.From)
 	_, err := bot.SendMessage(
-		tu.Message(tu.ID(chatID), h.data.Temp("help", message)).
+		tu.Message(tu.ID(chatID), h.temp(ctx, locale, "help", message)).
 			WithParseMode(telego.ModeHTML).
 			WithReplyMarkup(tu.InlineKeyboard(
 				tu.InlineKeyboardRow(
-					tu.InlineKeyboardButton(h.data.Text("siteButtonText")).
-						WithURL(h.data.Text("siteURL")),
+					tu.InlineKeyboardButton(h.text(ctx, locale, "siteButtonText")).
+						WithURL(h.text(ctx, locale, "siteURL")),
 				),
 				tu.InlineKeyboardRow(
-					tu.InlineKeyboardButton(h.data.Text("instagramButtonText")).
-						WithURL(h.data.Text("instagramURL")),
-					tu.InlineKeyboardButton(h.data.Text("facebookButtonText")).
-						WithURL(h.data.Text("facebookURL")),
+					tu.InlineKeyboardButton(h.text(ctx, locale, "instagramButtonText")).
+						WithURL(h.text(ctx, locale, "instagramURL")),
+					tu.InlineKeyboardButton(h.text(ctx, locale, "facebookButtonText")).
+						WithURL(h.text(ctx, locale, "facebookURL")),
 				),
 			)),
 	)
 	if err != nil {
-		h.log.Errorf("Send help message: %s", err)
+		log.Errorf("Send help message: %s", err)
+	}
+}
+
+func (h *Handler) closeCmd(bot *telego.Bot, update telego.Update) {
+	ctx := update.Context()
+	message := *update.Message
+	log := h.logFor(ctx)
+	_, args := tu.ParseCommand(message.Text)
+	reason := strings.Join(args, " ")
+	locale := h.userLocale(message.From)
+
+	// Reload keeps closure of current working hours, so it must not replace them in the meantime
+	h.reloadLock.Lock()
+	h.workingHours().Close(reason)
+	h.reloadLock.Unlock()
+	log.Infof("Closed by %d, reason: %q", message.From.ID, reason)
+
+	_, err := bot.SendMessage(tu.Message(tu.ID(message.Chat.ID), h.text(ctx, locale, "closeCommandDone")))
+	if err != nil {
+		log.Errorf("Send close message: %s", err)
+	}
+}
+
+func (h *Handler) openCmd(bot *telego.Bot, update telego.Update) {
+	ctx := update.Context()
+	message := *update.Message
+	log := h.logFor(ctx)
+	locale := h.userLocale(message.From)
+	h.reloadLock.Lock()
+	h.workingHours().Open()
+	h.reloadLock.Unlock()
+	log.Infof("Opened by %d", message.From.ID)
+
+	_, err := bot.SendMessage(tu.Message(tu.ID(message.Chat.ID), h.text(ctx, locale, "openCommandDone")))
+	if err != nil {
+		log.Errorf("Send open message: %s", err)
+	}
+}
+
+func (h *Handler) reloadCmd(bot *telego.Bot, update telego.Update) {
+	ctx := update.Context()
+	message := *update.Message
+	log := h.logFor(ctx)
+	var text string
+	if err := h.Reload(); err != nil {
+		log.Errorf("Reload by %d: %s", message.From.ID, err)
+		text = h.temp(ctx, h.userLocale(message.From), "reloadFailed", err.Error())
+	} else {
+		log.Infof("Reloaded by %d", message.From.ID)
+		text = h.text(ctx, h.userLocale(message.From), "reloadDone")
+	}
+
+	_, err := bot.SendMessage(tu.Message(tu.ID(message.Chat.ID), text))
+	if err != nil {
+		log.Errorf("Send reload message: %s", err)
+	}
+}
+
+const languageCallbackPrefix = "language:"
+
+func (h *Handler) languageCmd(bot *telego.Bot, update telego.Update) {
+	ctx := update.Context()
+	message := *update.Message
+	log := h.logFor(ctx)
+	locale := h.userLocale(message.From)
+
+	locales := h.textData().Locales()
+	rows := make([][]telego.InlineKeyboardButton, 0, len(locales))
+	for _, l := range locales {
+		rows = append(rows, tu.InlineKeyboardRow(
+			tu.InlineKeyboardButton(h.text(ctx, l, "languageName")).WithCallbackData(languageCallbackPrefix+l),
+		))
+	}
+
+	_, err := bot.SendMessage(tu.Message(tu.ID(message.Chat.ID), h.text(ctx, locale, "languageSelect")).
+		WithReplyMarkup(tu.InlineKeyboard(rows...)))
+	if err != nil {
+		log.Errorf("Send language message: %s", err)
+	}
+}
+
+func (h *Handler) languageSelected(bot *telego.Bot, update telego.Update) {
+	ctx := update.Context()
+	query := *update.CallbackQuery
+	log := h.logFor(ctx)
+	locale := h.textData().Locale(strings.TrimPrefix(query.Data, languageCallbackPrefix))
+	memkey.Set(h.locales, query.From.ID, locale)
+
+	err := bot.AnswerCallbackQuery(tu.CallbackQuery(query.ID).
+		WithText(h.temp(ctx, locale, "languageChanged", h.text(ctx, locale, "languageName"))))
+	if err != nil {
+		log.Errorf("Answer language callback: %s", err)
 	}
 }
 
-func (h *Handler) unknown(bot *telego.Bot, message telego.Message) {
-	_, err := bot.SendMessage(tu.Message(tu.ID(message.Chat.ID), h.data.Text("unknownMessage")))
+func (h *Handler) unknown(bot *telego.Bot, update telego.Update) {
+	ctx := update.Context()
+	message := *update.Message
+	log := h.logFor(ctx)
+	locale := h.userLocale(message.From)
+	_, err := bot.SendMessage(tu.Message(tu.ID(message.Chat.ID), h.text(ctx, locale, "unknownMessage")))
 	if err != nil {
-		h.log.Errorf("Send unknown message: %s", err)
+		log.Errorf("Send unknown message: %s", err)
 	}
 }
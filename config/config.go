@@ -2,7 +2,9 @@ package config
 
 import (
 	"fmt"
+	"io"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/BurntSushi/toml"
@@ -18,57 +20,104 @@ const (
 	googleMapsAPIKeyEnv = "GOOGLE_MAPS_API_KEY"
 	syodoAPIKeyEnv      = "SYODO_API_KEY"
 	liqPayPrivetKeyEnv  = "LIQ_PAY_PRIVET_KEY"
+	adminTokenEnv       = "ADMIN_TOKEN"
 )
 
-// LoadConfig loads config from config file and environment variables
+// defaultAdminHost represents address of admin server if not set, only local access is allowed by default
+const defaultAdminHost = "localhost:8081"
+
+// LoadConfig loads config from config file and environment variables, any field can be overridden by environment
+// variable named SYODO_<SECTION>_<FIELD> (e.g. SYODO_SETTINGS_ORDER_TTL), secrets are read by DefaultSecretProvider,
+// all found problems are returned at once as Problems
 func LoadConfig(filename string) (*Config, error) {
-	cfg := &Config{}
+	return LoadConfigWithSecrets(filename, DefaultSecretProvider())
+}
 
-	_, err := toml.DecodeFile(filename, cfg)
+// LoadConfigWithSecrets loads config like LoadConfig, but reads secrets from specified provider
+func LoadConfigWithSecrets(filename string, secretProvider SecretProvider) (*Config, error) {
+	cfg := &Config{
+		Settings: Settings{
+			AdminHost: defaultAdminHost,
+		},
+	}
+
+	meta, err := toml.DecodeFile(filename, cfg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to decode config: %w", err)
 	}
 
-	var ok bool
+	problems := undecodedProblems(meta)
+	problems = append(problems, cfg.applyEnv()...)
 
-	cfg.App.BotToken, ok = os.LookupEnv(botTokenEnv)
-	if !ok {
-		return nil, fmt.Errorf("no %q environment variable", botTokenEnv)
+	secrets := []struct {
+		name  string
+		value *string
+	}{
+		{name: botTokenEnv, value: &cfg.App.BotToken},
+		{name: providerTokenEnv, value: &cfg.App.ProviderToken},
+		{name: liqPayPrivetKeyEnv, value: &cfg.App.LiqPayPrivetKeyEnv},
+		{name: googleMapsAPIKeyEnv, value: &cfg.App.GoogleMapsAPIKey},
+		{name: syodoAPIKeyEnv, value: &cfg.App.SyodoAPIKey},
+		{name: adminTokenEnv, value: &cfg.App.AdminToken},
 	}
 
-	cfg.App.ProviderToken, ok = os.LookupEnv(providerTokenEnv)
-	if !ok {
-		return nil, fmt.Errorf("no %q environment variable", providerTokenEnv)
+	for _, secret := range secrets {
+		var ok bool
+		*secret.value, ok, err = secretProvider.Secret(secret.name)
+		if err != nil {
+			problems = append(problems, fmt.Errorf("secret %q: %w", secret.name, err))
+		} else if !ok {
+			problems = append(problems, fmt.Errorf("no %q secret, set %q or %q environment variable", secret.name,
+				secret.name, secret.name+secretFileSuffix))
+		}
 	}
 
-	cfg.App.LiqPayPrivetKeyEnv, ok = os.LookupEnv(liqPayPrivetKeyEnv)
-	if !ok {
-		return nil, fmt.Errorf("no %q environment variable", liqPayPrivetKeyEnv)
+	problems = append(problems, validationProblems(validator.New(), cfg)...)
+	problems = append(problems, cfg.consistencyProblems()...)
+	if len(problems) > 0 {
+		return nil, problems
 	}
 
-	cfg.App.GoogleMapsAPIKey, ok = os.LookupEnv(googleMapsAPIKeyEnv)
-	if !ok {
-		return nil, fmt.Errorf("no %q environment variable", googleMapsAPIKeyEnv)
-	}
+	return cfg, nil
+}
+
+// Print writes effective config in TOML format with secrets masked
+func (c *Config) Print(w io.Writer) error {
+	masked := *c
+	masked.App.BotToken = maskSecret(c.App.BotToken)
+	masked.App.ProviderToken = maskSecret(c.App.ProviderToken)
+	masked.App.LiqPayPrivetKeyEnv = maskSecret(c.App.LiqPayPrivetKeyEnv)
+	masked.App.GoogleMapsAPIKey = maskSecret(c.App.GoogleMapsAPIKey)
+	masked.App.SyodoAPIKey = maskSecret(c.App.SyodoAPIKey)
+	masked.App.AdminToken = maskSecret(c.App.AdminToken)
 
-	cfg.App.SyodoAPIKey, ok = os.LookupEnv(syodoAPIKeyEnv)
-	if !ok {
-		return nil, fmt.Errorf("no %q environment variable", syodoAPIKeyEnv)
+	if err := toml.NewEncoder(w).Encode(masked); err != nil {
+		return fmt.Errorf("encode config: %w", err)
 	}
 
-	validate := validator.New()
-	if err = validate.Struct(cfg); err != nil {
-		return nil, fmt.Errorf("config validation: %w", err)
+	return nil
+}
+
+const secretVisiblePrefix = 4
+
+// maskSecret hides secret keeping only first characters to be able to distinguish secrets
+func maskSecret(secret string) string {
+	if len(secret) <= secretVisiblePrefix*2 {
+		return strings.Repeat("*", len(secret))
 	}
 
-	return cfg, nil
+	return secret[:secretVisiblePrefix] + strings.Repeat("*", len(secret)-secretVisiblePrefix)
 }
 
 // Config represents general config structure
 type Config struct {
-	Log      Log
-	Settings Settings
-	App      App
+	Log       Log
+	Settings  Settings
+	Health    Health
+	CORS      CORS
+	RateLimit RateLimit
+	Schedule  Schedule
+	App       App
 }
 
 // Log represents logger config
@@ -76,31 +125,105 @@ type Log struct {
 	Level       string `validate:"required,oneof=error warn info debug"`
 	Destination string `validate:"required,oneof=stdout stderr file"`
 	Filename    string `validate:"required_if=Destination file"`
+	Format      string `validate:"omitempty,oneof=text json"`
+	Rotation    LogRotation
+	// NoRedaction disables masking of personal data and secrets, should be used only for local debugging
+	NoRedaction bool `validate:"-"`
+}
+
+// LogRotation represents rotation settings of log file, zero values disable corresponding rule
+type LogRotation struct {
+	MaxSizeMB int           `validate:"gte=0"`
+	Interval  time.Duration `validate:"gte=0"`
+	MaxFiles  int           `validate:"gte=0"`
+	MaxAge    time.Duration `validate:"gte=0"`
+	Compress  bool          `validate:"-"`
 }
 
 // Settings represents general settings
 type Settings struct {
 	StopTimeout        time.Duration `validate:"gte=0"`
-	UseLongPulling     bool          `validate:"-"`
+	UseLongPolling     bool          `validate:"-"`
 	ServerHost         string        `validate:"hostname_port"`
-	WebhookURL         string        `validate:"url"`
+	AdminHost          string        `validate:"hostname_port"`
+	WebhookURL         string        `validate:"omitempty,url"`
 	LongPollingTimeout int           `validate:"gte=0"`
 	RequestTimeout     time.Duration `validate:"gt=0"`
 	TestMode           bool          `validate:"-"`
 	OrderTTL           time.Duration `validate:"gt=0"`
+	// InitDataMaxAge represents max age of web app init data accepted with orders, older data is rejected to prevent
+	// replay
+	InitDataMaxAge time.Duration `validate:"gt=0"`
+	// IdempotencyWindow represents time within which the same order of the same user is treated as duplicate if
+	// client doesn't provide idempotency key, zero disables detection of duplicates without key
+	IdempotencyWindow time.Duration `validate:"gte=0"`
+}
+
+// Health represents readiness check settings
+type Health struct {
+	SyodoTimeout time.Duration `validate:"gt=0"`
+	MapsTimeout  time.Duration `validate:"gt=0"`
+}
+
+// CORS represents cross-origin requests settings of web app API, origins are in form of scheme://host[:port] or *
+// for any origin
+type CORS struct {
+	AllowedOrigins []string      `validate:"dive,url|eq=*"`
+	AllowedMethods []string      `validate:"dive,required"`
+	AllowedHeaders []string      `validate:"dive,required"`
+	MaxAge         time.Duration `validate:"gte=0"`
+}
+
+// RateLimit represents limits of order requests, requests are checked by client IP and by Telegram user
+type RateLimit struct {
+	PerUser Limit
+	PerIP   Limit
+	// RealIPHeader represents header with client IP set by proxy (e.g. X-Real-IP or X-Forwarded-For), it's used
+	// only for requests from trusted proxies, remote address is used if empty
+	RealIPHeader string `validate:"-"`
+	// TrustedProxies represents IPs or CIDRs of proxies allowed to set real IP header
+	TrustedProxies []string `validate:"dive,ip|cidr"`
+}
+
+// Limit represents token bucket limit, one request is allowed per interval with up to burst requests at once, zero
+// interval disables limit
+type Limit struct {
+	Interval time.Duration `validate:"gte=0"`
+	Burst    int           `validate:"required_with=Interval,gte=0"`
+}
+
+// Schedule represents working hours and pre-order settings, all times are in Syodo timezone
+type Schedule struct {
+	OpenTime        string                `validate:"datetime=15:04"`
+	CloseTime       string                `validate:"datetime=15:04"`
+	Weekdays        map[string]WorkingDay `validate:"dive,keys,oneof=monday tuesday wednesday thursday friday saturday sunday,endkeys"` //nolint:lll
+	Holidays        []string              `validate:"dive,datetime=2006-01-02"`
+	MinPreorderTime time.Duration         `validate:"gte=0"`
+	MaxPreorderDays int                   `validate:"gte=0"`
+}
+
+// WorkingDay represents working hours of specific weekday, empty times are taken from Schedule
+type WorkingDay struct {
+	OpenTime  string `validate:"omitempty,datetime=15:04"`
+	CloseTime string `validate:"omitempty,datetime=15:04"`
+	Closed    bool   `validate:"-"`
 }
 
 // App represents business logic settings
 type App struct {
-	BotToken           string `validate:"required"`
-	ProviderToken      string `validate:"required"`
-	LiqPayPrivetKeyEnv string `validate:"required"`
-	GoogleMapsAPIKey   string `validate:"required"`
-	SyodoAPIKey        string `validate:"required"`
-	WebAppURL          string `validate:"url"`
-	SyodoAPIURL        string `validate:"url"`
+	BotToken           string  `validate:"required"`
+	ProviderToken      string  `validate:"required"`
+	LiqPayPrivetKeyEnv string  `validate:"required"`
+	GoogleMapsAPIKey   string  `validate:"required"`
+	SyodoAPIKey        string  `validate:"required"`
+	AdminToken         string  `validate:"required,min=16"`
+	WebAppURL          string  `validate:"url"`
+	SyodoAPIURL        string  `validate:"url"`
+	AdminIDs           []int64 `validate:"dive,gt=0"`
 }
 
+const bytesInMB = 1 << 20
+
 const (
 	logDestinationStdout = "stdout"
 	logDestinationStderr = "stderr"
@@ -122,13 +245,35 @@ func (c *Config) ConfigureLogger(log *logger.Log) error {
 	case logDestinationStderr:
 		log.SetOutput(os.Stderr)
 	case logDestinationFile:
-		if err := log.SetOutputFile(c.Log.Filename); err != nil {
+		if err := log.SetOutputFile(c.Log.Filename, logger.Rotation{
+			MaxSize:  int64(c.Log.Rotation.MaxSizeMB) * bytesInMB,
+			Interval: c.Log.Rotation.Interval,
+			MaxFiles: c.Log.Rotation.MaxFiles,
+			MaxAge:   c.Log.Rotation.MaxAge,
+			Compress: c.Log.Rotation.Compress,
+		}); err != nil {
 			return err
 		}
 	default:
 		return fmt.Errorf("unknown logger destination: %q", c.Log.Destination)
 	}
 
+	format := c.Log.Format
+	if format == "" {
+		format = logger.FormatText
+	}
+	if err := log.SetFormat(format); err != nil {
+		return err
+	}
+
+	if c.Log.NoRedaction {
+		log.SetRedactor(nil)
+		log.Warn("Redaction of personal data and secrets in logs is disabled")
+	} else {
+		log.SetRedactor(logger.NewRedactor(c.App.BotToken, c.App.ProviderToken, c.App.LiqPayPrivetKeyEnv,
+			c.App.GoogleMapsAPIKey, c.App.SyodoAPIKey, c.App.AdminToken))
+	}
+
 	switch c.Log.Level {
 	case logLevelError, logLevelWarn, logLevelInfo, logLevelDebug:
 		log.SetLevel(c.Log.Level)
@@ -0,0 +1,39 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPrint(t *testing.T) {
+	cfg := &Config{
+		App: App{
+			BotToken:    "123456789:AAHdqTcvCH1vGWJxfSeofSAs0K5PALDsaw0",
+			AdminToken:  "admin-token-secret",
+			SyodoAPIKey: "short",
+			WebAppURL:   "https://syodo.com.ua",
+		},
+	}
+
+	var buf strings.Builder
+	if err := cfg.Print(&buf); err != nil {
+		t.Fatal(err)
+	}
+	printed := buf.String()
+
+	for _, secret := range []string{cfg.App.BotToken, cfg.App.AdminToken, "AAHdqTcvCH1vGWJxfSeofSAs0K5PALDsaw0"} {
+		if strings.Contains(printed, secret) {
+			t.Errorf("secret %q not masked:\n%s", secret, printed)
+		}
+	}
+
+	for _, expected := range []string{`"1234*****`, `"admi*****`, `SyodoAPIKey = "*****"`, cfg.App.WebAppURL} {
+		if !strings.Contains(printed, expected) {
+			t.Errorf("expected %q in:\n%s", expected, printed)
+		}
+	}
+
+	if cfg.App.BotToken != "123456789:AAHdqTcvCH1vGWJxfSeofSAs0K5PALDsaw0" {
+		t.Error("expected config not to be modified")
+	}
+}
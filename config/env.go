@@ -0,0 +1,175 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// envPrefix represents prefix of environment variables that override config fields
+const envPrefix = "SYODO_"
+
+// envValue represents config field that can be set from environment variable
+type envValue interface {
+	Set(value string) error
+}
+
+type stringValue struct{ p *string }
+
+func (v stringValue) Set(value string) error {
+	*v.p = value
+	return nil
+}
+
+type boolValue struct{ p *bool }
+
+func (v boolValue) Set(value string) error {
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return err
+	}
+
+	*v.p = b
+	return nil
+}
+
+type intValue struct{ p *int }
+
+func (v intValue) Set(value string) error {
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		return err
+	}
+
+	*v.p = i
+	return nil
+}
+
+type durationValue struct{ p *time.Duration }
+
+func (v durationValue) Set(value string) error {
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return err
+	}
+
+	*v.p = d
+	return nil
+}
+
+// stringsValue represents comma separated list of strings
+type stringsValue struct{ p *[]string }
+
+func (v stringsValue) Set(value string) error {
+	*v.p = splitList(value)
+	return nil
+}
+
+// int64sValue represents comma separated list of integers
+type int64sValue struct{ p *[]int64 }
+
+func (v int64sValue) Set(value string) error {
+	items := splitList(value)
+	ints := make([]int64, len(items))
+	for i, item := range items {
+		var err error
+		ints[i], err = strconv.ParseInt(item, 10, 64)
+		if err != nil {
+			return err
+		}
+	}
+
+	*v.p = ints
+	return nil
+}
+
+func splitList(value string) []string {
+	items := strings.Split(value, ",")
+	list := make([]string, 0, len(items))
+	for _, item := range items {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+
+	return list
+}
+
+// envBinding represents environment variable name (without prefix) and config field it overrides
+type envBinding struct {
+	name  string
+	value envValue
+}
+
+// envBindings returns all config fields that can be overridden by environment variables, schedule weekdays can be
+// set only in config file
+func (c *Config) envBindings() []envBinding {
+	return []envBinding{
+		{name: "LOG_LEVEL", value: stringValue{&c.Log.Level}},
+		{name: "LOG_DESTINATION", value: stringValue{&c.Log.Destination}},
+		{name: "LOG_FILENAME", value: stringValue{&c.Log.Filename}},
+		{name: "LOG_FORMAT", value: stringValue{&c.Log.Format}},
+		{name: "LOG_NO_REDACTION", value: boolValue{&c.Log.NoRedaction}},
+		{name: "LOG_ROTATION_MAX_SIZE_MB", value: intValue{&c.Log.Rotation.MaxSizeMB}},
+		{name: "LOG_ROTATION_INTERVAL", value: durationValue{&c.Log.Rotation.Interval}},
+		{name: "LOG_ROTATION_MAX_FILES", value: intValue{&c.Log.Rotation.MaxFiles}},
+		{name: "LOG_ROTATION_MAX_AGE", value: durationValue{&c.Log.Rotation.MaxAge}},
+		{name: "LOG_ROTATION_COMPRESS", value: boolValue{&c.Log.Rotation.Compress}},
+
+		{name: "SETTINGS_STOP_TIMEOUT", value: durationValue{&c.Settings.StopTimeout}},
+		{name: "SETTINGS_USE_LONG_POLLING", value: boolValue{&c.Settings.UseLongPolling}},
+		{name: "SETTINGS_SERVER_HOST", value: stringValue{&c.Settings.ServerHost}},
+		{name: "SETTINGS_ADMIN_HOST", value: stringValue{&c.Settings.AdminHost}},
+		{name: "SETTINGS_WEBHOOK_URL", value: stringValue{&c.Settings.WebhookURL}},
+		{name: "SETTINGS_LONG_POLLING_TIMEOUT", value: intValue{&c.Settings.LongPollingTimeout}},
+		{name: "SETTINGS_REQUEST_TIMEOUT", value: durationValue{&c.Settings.RequestTimeout}},
+		{name: "SETTINGS_TEST_MODE", value: boolValue{&c.Settings.TestMode}},
+		{name: "SETTINGS_ORDER_TTL", value: durationValue{&c.Settings.OrderTTL}},
+		{name: "SETTINGS_INIT_DATA_MAX_AGE", value: durationValue{&c.Settings.InitDataMaxAge}},
+		{name: "SETTINGS_IDEMPOTENCY_WINDOW", value: durationValue{&c.Settings.IdempotencyWindow}},
+
+		{name: "HEALTH_SYODO_TIMEOUT", value: durationValue{&c.Health.SyodoTimeout}},
+		{name: "HEALTH_MAPS_TIMEOUT", value: durationValue{&c.Health.MapsTimeout}},
+
+		{name: "CORS_ALLOWED_ORIGINS", value: stringsValue{&c.CORS.AllowedOrigins}},
+		{name: "CORS_ALLOWED_METHODS", value: stringsValue{&c.CORS.AllowedMethods}},
+		{name: "CORS_ALLOWED_HEADERS", value: stringsValue{&c.CORS.AllowedHeaders}},
+		{name: "CORS_MAX_AGE", value: durationValue{&c.CORS.MaxAge}},
+
+		{name: "RATE_LIMIT_PER_USER_INTERVAL", value: durationValue{&c.RateLimit.PerUser.Interval}},
+		{name: "RATE_LIMIT_PER_USER_BURST", value: intValue{&c.RateLimit.PerUser.Burst}},
+		{name: "RATE_LIMIT_PER_IP_INTERVAL", value: durationValue{&c.RateLimit.PerIP.Interval}},
+		{name: "RATE_LIMIT_PER_IP_BURST", value: intValue{&c.RateLimit.PerIP.Burst}},
+		{name: "RATE_LIMIT_REAL_IP_HEADER", value: stringValue{&c.RateLimit.RealIPHeader}},
+		{name: "RATE_LIMIT_TRUSTED_PROXIES", value: stringsValue{&c.RateLimit.TrustedProxies}},
+
+		{name: "SCHEDULE_OPEN_TIME", value: stringValue{&c.Schedule.OpenTime}},
+		{name: "SCHEDULE_CLOSE_TIME", value: stringValue{&c.Schedule.CloseTime}},
+		{name: "SCHEDULE_HOLIDAYS", value: stringsValue{&c.Schedule.Holidays}},
+		{name: "SCHEDULE_MIN_PREORDER_TIME", value: durationValue{&c.Schedule.MinPreorderTime}},
+		{name: "SCHEDULE_MAX_PREORDER_DAYS", value: intValue{&c.Schedule.MaxPreorderDays}},
+
+		{name: "APP_WEB_APP_URL", value: stringValue{&c.App.WebAppURL}},
+		{name: "APP_SYODO_API_URL", value: stringValue{&c.App.SyodoAPIURL}},
+		{name: "APP_ADMIN_IDS", value: int64sValue{&c.App.AdminIDs}},
+	}
+}
+
+// applyEnv overrides config fields with values of environment variables, lists are comma separated
+func (c *Config) applyEnv() Problems {
+	var problems Problems
+	for _, binding := range c.envBindings() {
+		value, ok := os.LookupEnv(envPrefix + binding.name)
+		if !ok {
+			continue
+		}
+
+		if err := binding.value.Set(value); err != nil {
+			problems = append(problems, fmt.Errorf("environment variable %q: %w", envPrefix+binding.name, err))
+		}
+	}
+
+	return problems
+}
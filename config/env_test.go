@@ -0,0 +1,123 @@
+package config
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// envExcludedKeys represents config keys that are not set by environment variables, secrets are read by secret
+// provider and weekdays can be set only in config file
+var envExcludedKeys = []string{
+	"App.BotToken", "App.ProviderToken", "App.LiqPayPrivetKeyEnv", "App.GoogleMapsAPIKey", "App.SyodoAPIKey",
+	"App.AdminToken", "Schedule.Weekdays",
+}
+
+// normalizeEnvName returns name in upper case without separators, so config keys (e.g. Log.Rotation.MaxSizeMB) can be
+// compared with environment variable names (e.g. LOG_ROTATION_MAX_SIZE_MB)
+func normalizeEnvName(name string) string {
+	return strings.ToUpper(strings.NewReplacer(".", "", "_", "").Replace(name))
+}
+
+func TestEnvBindingsCoverAllFields(t *testing.T) {
+	cfg := &Config{
+		CORS:      CORS{AllowedOrigins: []string{"*"}, AllowedMethods: []string{"POST"}, AllowedHeaders: []string{"X"}},
+		RateLimit: RateLimit{TrustedProxies: []string{"127.0.0.1"}},
+		Schedule:  Schedule{Holidays: []string{"2023-01-01"}},
+		App:       App{AdminIDs: []int64{1}},
+	}
+
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	meta, err := toml.Decode(buf.String(), &Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bindings := make(map[string]string)
+	for _, binding := range cfg.envBindings() {
+		normalized := normalizeEnvName(binding.name)
+		if _, ok := bindings[normalized]; ok {
+			t.Errorf("duplicated binding %q", binding.name)
+		}
+		bindings[normalized] = binding.name
+	}
+
+	for _, key := range meta.Keys() {
+		name := key.String()
+		if meta.Type(key...) == "Hash" || hasKeyPrefix(name, envExcludedKeys) {
+			continue
+		}
+
+		normalized := normalizeEnvName(name)
+		if _, ok := bindings[normalized]; !ok {
+			t.Errorf("no environment variable for config key %q", name)
+		}
+		delete(bindings, normalized)
+	}
+
+	for _, name := range bindings {
+		t.Errorf("environment variable %q doesn't match any config key", name)
+	}
+}
+
+func hasKeyPrefix(key string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if key == prefix || strings.HasPrefix(key, prefix+".") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func TestApplyEnv(t *testing.T) {
+	t.Setenv(envPrefix+"LOG_LEVEL", "debug")
+	t.Setenv(envPrefix+"SETTINGS_USE_LONG_POLLING", "true")
+	t.Setenv(envPrefix+"SETTINGS_ORDER_TTL", "1h30m")
+	t.Setenv(envPrefix+"LOG_ROTATION_MAX_FILES", "7")
+	t.Setenv(envPrefix+"CORS_ALLOWED_ORIGINS", "https://syodo.com.ua, ,https://t.me")
+	t.Setenv(envPrefix+"APP_ADMIN_IDS", "1,2")
+
+	cfg := &Config{}
+	if problems := cfg.applyEnv(); len(problems) != 0 {
+		t.Fatalf("unexpected problems: %s", problems)
+	}
+
+	if cfg.Log.Level != "debug" || !cfg.Settings.UseLongPolling || cfg.Settings.OrderTTL != 90*time.Minute ||
+		cfg.Log.Rotation.MaxFiles != 7 {
+		t.Errorf("unexpected config: %+v", cfg)
+	}
+
+	if origins := cfg.CORS.AllowedOrigins; len(origins) != 2 || origins[0] != "https://syodo.com.ua" ||
+		origins[1] != "https://t.me" {
+		t.Errorf("unexpected origins: %v", origins)
+	}
+
+	if ids := cfg.App.AdminIDs; len(ids) != 2 || ids[0] != 1 || ids[1] != 2 {
+		t.Errorf("unexpected admin IDs: %v", ids)
+	}
+}
+
+func TestApplyEnvInvalid(t *testing.T) {
+	t.Setenv(envPrefix+"LOG_ROTATION_COMPRESS", "maybe")
+	t.Setenv(envPrefix+"SETTINGS_STOP_TIMEOUT", "5")
+	t.Setenv(envPrefix+"APP_ADMIN_IDS", "1,admin")
+
+	problems := (&Config{}).applyEnv()
+	if len(problems) != 3 {
+		t.Fatalf("expected 3 problems, got: %s", problems)
+	}
+
+	for _, name := range []string{"LOG_ROTATION_COMPRESS", "SETTINGS_STOP_TIMEOUT", "APP_ADMIN_IDS"} {
+		if !strings.Contains(problems.Error(), envPrefix+name) {
+			t.Errorf("expected problem of %q, got: %s", name, problems)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// secretFileSuffix represents suffix of environment variable that contains path to file with secret
+const secretFileSuffix = "_FILE"
+
+// secretFileForbiddenPerm represents permission bits that secret file must not have, secret should be accessible
+// only by its owner (e.g. 0400 or 0600)
+const secretFileForbiddenPerm = 0o077
+
+// credentialsDirEnv represents environment variable set by systemd for services with credentials
+const credentialsDirEnv = "CREDENTIALS_DIRECTORY"
+
+// SecretProvider represents source of secrets, ok is false if provider doesn't have requested secret
+type SecretProvider interface {
+	Secret(name string) (value string, ok bool, err error)
+}
+
+// SecretProviders represents chain of secret providers, the first provider that has a secret wins
+type SecretProviders []SecretProvider
+
+// Secret returns secret from the first provider that has it
+func (p SecretProviders) Secret(name string) (string, bool, error) {
+	for _, provider := range p {
+		value, ok, err := provider.Secret(name)
+		if err != nil || ok {
+			return value, ok, err
+		}
+	}
+
+	return "", false, nil
+}
+
+// EnvSecretProvider represents secrets from environment variables, secret can be set as is (e.g. BOT_TOKEN) or as path
+// to file with it (e.g. BOT_TOKEN_FILE), setting both is an error
+type EnvSecretProvider struct{}
+
+// Secret returns secret from environment variable or from file specified by it
+func (EnvSecretProvider) Secret(name string) (string, bool, error) {
+	value, ok := os.LookupEnv(name)
+	filename, fileOk := os.LookupEnv(name + secretFileSuffix)
+
+	switch {
+	case ok && fileOk:
+		return "", false, fmt.Errorf("both %q and %q environment variables set", name, name+secretFileSuffix)
+	case fileOk:
+		value, err := readSecretFile(filename)
+		if err != nil {
+			return "", false, fmt.Errorf("%q environment variable: %w", name+secretFileSuffix, err)
+		}
+		return value, true, nil
+	default:
+		return value, ok, nil
+	}
+}
+
+// DirSecretProvider represents secrets stored as files in directory named by secrets (e.g. Docker secrets in
+// /run/secrets or systemd credentials)
+type DirSecretProvider struct {
+	Dir string
+}
+
+// Secret returns secret from file with the same name in directory, if there is one
+func (p DirSecretProvider) Secret(name string) (string, bool, error) {
+	filename := filepath.Join(p.Dir, name)
+	if _, err := os.Stat(filename); err != nil {
+		if os.IsNotExist(err) {
+			return "", false, nil
+		}
+		return "", false, fmt.Errorf("stat secret file: %w", err)
+	}
+
+	value, err := readSecretFile(filename)
+	if err != nil {
+		return "", false, err
+	}
+
+	return value, true, nil
+}
+
+// DefaultSecretProvider returns secret provider that reads environment variables and systemd credentials if
+// available
+func DefaultSecretProvider() SecretProvider {
+	providers := SecretProviders{EnvSecretProvider{}}
+	if dir, ok := os.LookupEnv(credentialsDirEnv); ok {
+		providers = append(providers, DirSecretProvider{Dir: dir})
+	}
+
+	return providers
+}
+
+// readSecretFile reads secret from file refusing files accessible by others than owner, trailing new line is removed
+func readSecretFile(filename string) (string, error) {
+	info, err := os.Stat(filename)
+	if err != nil {
+		return "", fmt.Errorf("stat secret file: %w", err)
+	}
+
+	if perm := info.Mode().Perm(); perm&secretFileForbiddenPerm != 0 {
+		return "", fmt.Errorf("secret file %q has too permissive permissions %#o, expected at most 0600",
+			filename, perm)
+	}
+
+	data, err := os.ReadFile(filename) //nolint:gosec
+	if err != nil {
+		return "", fmt.Errorf("read secret file: %w", err)
+	}
+
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
@@ -0,0 +1,92 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeSecretFile(t *testing.T, dir, name, value string, perm os.FileMode) string {
+	t.Helper()
+
+	filename := filepath.Join(dir, name)
+	if err := os.WriteFile(filename, []byte(value), perm); err != nil {
+		t.Fatal(err)
+	}
+	// Permissions are set explicitly, so they don't depend on umask
+	if err := os.Chmod(filename, perm); err != nil {
+		t.Fatal(err)
+	}
+
+	return filename
+}
+
+func TestEnvSecretProvider(t *testing.T) {
+	dir := t.TempDir()
+	provider := EnvSecretProvider{}
+
+	t.Setenv("TEST_SECRET", "value")
+	if value, ok, err := provider.Secret("TEST_SECRET"); err != nil || !ok || value != "value" {
+		t.Errorf("expected secret from variable, got: %q, %t, %v", value, ok, err)
+	}
+
+	if _, ok, err := provider.Secret("TEST_UNKNOWN_SECRET"); err != nil || ok {
+		t.Errorf("expected no secret, got: %t, %v", ok, err)
+	}
+
+	t.Setenv("TEST_FILE_SECRET_FILE", writeSecretFile(t, dir, "file-secret", "file-value\n", 0o600))
+	if value, ok, err := provider.Secret("TEST_FILE_SECRET"); err != nil || !ok || value != "file-value" {
+		t.Errorf("expected secret from file, got: %q, %t, %v", value, ok, err)
+	}
+
+	t.Setenv("TEST_BOTH_SECRET", "value")
+	t.Setenv("TEST_BOTH_SECRET_FILE", writeSecretFile(t, dir, "both-secret", "file-value", 0o600))
+	if _, _, err := provider.Secret("TEST_BOTH_SECRET"); err == nil || !strings.Contains(err.Error(), "both") {
+		t.Errorf("expected error for both variables set, got: %v", err)
+	}
+
+	t.Setenv("TEST_OPEN_SECRET_FILE", writeSecretFile(t, dir, "open-secret", "file-value", 0o644))
+	if _, _, err := provider.Secret("TEST_OPEN_SECRET"); err == nil || !strings.Contains(err.Error(), "permissive") {
+		t.Errorf("expected error for permissive file, got: %v", err)
+	}
+
+	t.Setenv("TEST_MISSING_SECRET_FILE", filepath.Join(dir, "missing-secret"))
+	if _, _, err := provider.Secret("TEST_MISSING_SECRET"); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
+
+func TestDirSecretProvider(t *testing.T) {
+	dir := t.TempDir()
+	provider := DirSecretProvider{Dir: dir}
+
+	writeSecretFile(t, dir, "BOT_TOKEN", "token\r\n", 0o400)
+	if value, ok, err := provider.Secret("BOT_TOKEN"); err != nil || !ok || value != "token" {
+		t.Errorf("expected secret from directory, got: %q, %t, %v", value, ok, err)
+	}
+
+	if _, ok, err := provider.Secret("SYODO_API_KEY"); err != nil || ok {
+		t.Errorf("expected no secret, got: %t, %v", ok, err)
+	}
+
+	writeSecretFile(t, dir, "ADMIN_TOKEN", "token", 0o640)
+	if _, _, err := provider.Secret("ADMIN_TOKEN"); err == nil {
+		t.Error("expected error for permissive file")
+	}
+}
+
+func TestSecretProviders(t *testing.T) {
+	dir := t.TempDir()
+	writeSecretFile(t, dir, "TEST_CHAIN_SECRET", "dir-value", 0o600)
+	providers := SecretProviders{EnvSecretProvider{}, DirSecretProvider{Dir: dir}}
+
+	if value, ok, err := providers.Secret("TEST_CHAIN_SECRET"); err != nil || !ok || value != "dir-value" {
+		t.Errorf("expected secret from directory, got: %q, %t, %v", value, ok, err)
+	}
+
+	t.Setenv("TEST_CHAIN_SECRET", "env-value")
+	if value, ok, err := providers.Secret("TEST_CHAIN_SECRET"); err != nil || !ok || value != "env-value" {
+		t.Errorf("expected secret from the first provider, got: %q, %t, %v", value, ok, err)
+	}
+}
@@ -0,0 +1,93 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/go-playground/validator/v10"
+)
+
+// liveProviderTokenMarker represents part of payment provider token issued for real payments
+const liveProviderTokenMarker = ":LIVE:"
+
+// Problems represents all problems found in config
+type Problems []error
+
+// Error returns all problems each on separate line
+func (p Problems) Error() string {
+	lines := make([]string, len(p))
+	for i, problem := range p {
+		lines[i] = "- " + problem.Error()
+	}
+
+	return fmt.Sprintf("%d config problem(s):\n%s", len(p), strings.Join(lines, "\n"))
+}
+
+// undecodedProblems returns problems for keys in config file that don't match any config field
+func undecodedProblems(meta toml.MetaData) Problems {
+	var problems Problems
+	for _, key := range meta.Undecoded() {
+		problems = append(problems, fmt.Errorf("unknown key %q", key.String()))
+	}
+
+	return problems
+}
+
+// validationProblems returns problems of validation by struct tags
+func validationProblems(validate *validator.Validate, cfg *Config) Problems {
+	err := validate.Struct(cfg)
+	if err == nil {
+		return nil
+	}
+
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return Problems{err}
+	}
+
+	problems := make(Problems, len(validationErrors))
+	for i, fieldErr := range validationErrors {
+		problems[i] = fieldErr
+	}
+
+	return problems
+}
+
+// consistencyProblems returns problems of fields that depend on each other
+func (c *Config) consistencyProblems() Problems {
+	var problems Problems
+
+	if !c.Settings.UseLongPolling {
+		if c.Settings.WebhookURL == "" {
+			problems = append(problems, errors.New("webhook URL is required when long polling is not used"))
+		} else if !c.Settings.TestMode && !isHTTPS(c.Settings.WebhookURL) {
+			problems = append(problems, errors.New("webhook URL should use https when test mode is disabled"))
+		}
+	}
+
+	if c.RateLimit.RealIPHeader != "" && len(c.RateLimit.TrustedProxies) == 0 {
+		problems = append(problems, errors.New("real IP header requires trusted proxies"))
+	}
+
+	if c.Settings.AdminHost == c.Settings.ServerHost {
+		problems = append(problems, errors.New("admin host should differ from server host"))
+	}
+
+	if !c.Settings.TestMode && !isHTTPS(c.App.WebAppURL) {
+		problems = append(problems, errors.New("web app URL should use https when test mode is disabled"))
+	}
+
+	if c.Settings.TestMode && strings.Contains(c.App.ProviderToken, liveProviderTokenMarker) {
+		problems = append(problems, errors.New("test mode can't be used with live payment provider token"))
+	}
+
+	return problems
+}
+
+func isHTTPS(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	return err == nil && u.Scheme == "https"
+}
@@ -0,0 +1,221 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// mapSecretProvider represents secrets stored in map
+type mapSecretProvider map[string]string
+
+func (p mapSecretProvider) Secret(name string) (string, bool, error) {
+	value, ok := p[name]
+	return value, ok, nil
+}
+
+var testSecrets = mapSecretProvider{
+	botTokenEnv:         "123456789:AAHdqTcvCH1vGWJxfSeofSAs0K5PALDsaw0",
+	providerTokenEnv:    "632593626:TEST:i56982357197",
+	liqPayPrivetKeyEnv:  "liq-pay-key",
+	googleMapsAPIKeyEnv: "google-maps-key",
+	syodoAPIKeyEnv:      "syodo-api-key",
+	adminTokenEnv:       "admin-token-secret",
+}
+
+const testConfig = `
+[log]
+level = "info"
+destination = "stdout"
+
+[settings]
+serverHost = "localhost:8080"
+adminHost = "localhost:8081"
+webhookURL = "https://telegrambot.syodo.com.ua/syodo-bot"
+requestTimeout = "10s"
+orderTTL = "30m"
+initDataMaxAge = "1h"
+
+[health]
+syodoTimeout = "3s"
+mapsTimeout = "3s"
+
+[schedule]
+openTime = "10:00"
+closeTime = "22:00"
+
+[app]
+webAppURL = "https://telegrambot.syodo.com.ua/syodo"
+syodoAPIURL = "https://syodo.com.ua/api"
+`
+
+func loadTestConfig(t *testing.T, data string) (*Config, error) {
+	t.Helper()
+
+	filename := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(filename, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return LoadConfigWithSecrets(filename, testSecrets)
+}
+
+func TestLoadConfigProblems(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		problems []string
+	}{
+		{
+			name: "valid",
+			data: testConfig,
+		},
+		{
+			name:     "unknown_key",
+			data:     strings.Replace(testConfig, "[health]", "[health]\nsyodoTimeoutt = \"1s\"", 1),
+			problems: []string{`unknown key "health.syodoTimeoutt"`},
+		},
+		{
+			name:     "unknown_section",
+			data:     testConfig + "\n[unknown]\nkey = 1\n",
+			problems: []string{`unknown key "unknown"`, `unknown key "unknown.key"`},
+		},
+		{
+			name: "multiple",
+			data: strings.NewReplacer(
+				`level = "info"`, `level = "verbose"`,
+				`openTime = "10:00"`, `openTime = "10am"`,
+				`adminHost = "localhost:8081"`, `adminHost = "localhost:8080"`,
+				"[health]", "[health]\nunknown = true",
+			).Replace(testConfig),
+			problems: []string{
+				`unknown key "health.unknown"`,
+				"Config.Log.Level",
+				"Config.Schedule.OpenTime",
+				"admin host should differ from server host",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := loadTestConfig(t, tt.data)
+			if len(tt.problems) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				if cfg == nil {
+					t.Fatal("expected config")
+				}
+				return
+			}
+
+			var problems Problems
+			if !errors.As(err, &problems) {
+				t.Fatalf("expected problems, got: %v", err)
+			}
+
+			if len(problems) != len(tt.problems) {
+				t.Errorf("expected %d problems, got: %s", len(tt.problems), problems)
+			}
+			for _, expected := range tt.problems {
+				if !strings.Contains(problems.Error(), expected) {
+					t.Errorf("expected problem %q, got: %s", expected, problems)
+				}
+			}
+		})
+	}
+}
+
+func TestConsistencyProblems(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(cfg *Config)
+		problem string
+	}{
+		{
+			name:   "valid",
+			modify: func(cfg *Config) {},
+		},
+		{
+			name: "long_polling_without_webhook",
+			modify: func(cfg *Config) {
+				cfg.Settings.UseLongPolling = true
+				cfg.Settings.WebhookURL = ""
+			},
+		},
+		{
+			name:    "no_webhook",
+			modify:  func(cfg *Config) { cfg.Settings.WebhookURL = "" },
+			problem: "webhook URL is required",
+		},
+		{
+			name:    "http_webhook",
+			modify:  func(cfg *Config) { cfg.Settings.WebhookURL = "http://telegrambot.syodo.com.ua/syodo-bot" },
+			problem: "webhook URL should use https",
+		},
+		{
+			name: "http_webhook_test_mode",
+			modify: func(cfg *Config) {
+				cfg.Settings.TestMode = true
+				cfg.Settings.WebhookURL = "http://localhost:8080/syodo-bot"
+				cfg.App.WebAppURL = "http://localhost:8080/syodo"
+			},
+		},
+		{
+			name:    "same_hosts",
+			modify:  func(cfg *Config) { cfg.Settings.AdminHost = cfg.Settings.ServerHost },
+			problem: "admin host should differ",
+		},
+		{
+			name:    "real_ip_header_without_proxies",
+			modify:  func(cfg *Config) { cfg.RateLimit.RealIPHeader = "X-Real-IP" },
+			problem: "real IP header requires trusted proxies",
+		},
+		{
+			name: "real_ip_header_with_proxies",
+			modify: func(cfg *Config) {
+				cfg.RateLimit.RealIPHeader = "X-Real-IP"
+				cfg.RateLimit.TrustedProxies = []string{"127.0.0.1"}
+			},
+		},
+		{
+			name:    "http_web_app",
+			modify:  func(cfg *Config) { cfg.App.WebAppURL = "http://telegrambot.syodo.com.ua/syodo" },
+			problem: "web app URL should use https",
+		},
+		{
+			name: "live_token_test_mode",
+			modify: func(cfg *Config) {
+				cfg.Settings.TestMode = true
+				cfg.App.ProviderToken = "632593626:LIVE:i56982357197"
+			},
+			problem: "live payment provider token",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := loadTestConfig(t, testConfig)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			tt.modify(cfg)
+			problems := cfg.consistencyProblems()
+
+			if tt.problem == "" {
+				if len(problems) != 0 {
+					t.Errorf("unexpected problems: %s", problems)
+				}
+				return
+			}
+
+			if len(problems) != 1 || !strings.Contains(problems[0].Error(), tt.problem) {
+				t.Errorf("expected problem %q, got: %v", tt.problem, problems)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/valyala/fasthttp"
+)
+
+// corsAnyOrigin allows requests from any origin if set in allowed origins
+const corsAnyOrigin = "*"
+
+// cors allows cross-origin requests only from configured origins, requests without origin (not from browser) are
+// passed as is
+func (h *Handler) cors(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+	return func(ctx *fasthttp.RequestCtx) {
+		origin := string(ctx.Request.Header.Peek(fasthttp.HeaderOrigin))
+		if origin == "" {
+			next(ctx)
+			return
+		}
+
+		ctx.Response.Header.Add(fasthttp.HeaderVary, fasthttp.HeaderOrigin)
+		if !h.corsOriginAllowed(origin) {
+			h.logFor(ctx).Warnf("CORS request from disallowed origin %q", origin)
+			ctx.SetStatusCode(fasthttp.StatusForbidden)
+			return
+		}
+
+		ctx.Response.Header.Set(fasthttp.HeaderAccessControlAllowOrigin, origin)
+		next(ctx)
+	}
+}
+
+// corsPreflight handles preflight requests, only allowed origins, methods and headers pass
+func (h *Handler) corsPreflight(ctx *fasthttp.RequestCtx) {
+	origin := string(ctx.Request.Header.Peek(fasthttp.HeaderOrigin))
+	method := string(ctx.Request.Header.Peek(fasthttp.HeaderAccessControlRequestMethod))
+	if origin == "" || method == "" {
+		ctx.SetStatusCode(fasthttp.StatusNoContent)
+		return
+	}
+
+	ctx.Response.Header.Add(fasthttp.HeaderVary, fasthttp.HeaderOrigin)
+	ctx.Response.Header.Add(fasthttp.HeaderVary, fasthttp.HeaderAccessControlRequestMethod)
+	ctx.Response.Header.Add(fasthttp.HeaderVary, fasthttp.HeaderAccessControlRequestHeaders)
+
+	log := h.logFor(ctx)
+	cfg := h.config().CORS
+
+	if !h.corsOriginAllowed(origin) {
+		log.Warnf("CORS preflight from disallowed origin %q", origin)
+		ctx.SetStatusCode(fasthttp.StatusForbidden)
+		return
+	}
+
+	if !containsFold(cfg.AllowedMethods, method) {
+		log.Warnf("CORS preflight from %q with disallowed method %q", origin, method)
+		ctx.SetStatusCode(fasthttp.StatusForbidden)
+		return
+	}
+
+	requestHeaders := string(ctx.Request.Header.Peek(fasthttp.HeaderAccessControlRequestHeaders))
+	for _, header := range strings.Split(requestHeaders, ",") {
+		header = strings.TrimSpace(header)
+		if header != "" && !containsFold(cfg.AllowedHeaders, header) {
+			log.Warnf("CORS preflight from %q with disallowed header %q", origin, header)
+			ctx.SetStatusCode(fasthttp.StatusForbidden)
+			return
+		}
+	}
+
+	ctx.Response.Header.Set(fasthttp.HeaderAccessControlAllowOrigin, origin)
+	ctx.Response.Header.Set(fasthttp.HeaderAccessControlAllowMethods, strings.Join(cfg.AllowedMethods, ", "))
+	if len(cfg.AllowedHeaders) > 0 {
+		ctx.Response.Header.Set(fasthttp.HeaderAccessControlAllowHeaders, strings.Join(cfg.AllowedHeaders, ", "))
+	}
+	if cfg.MaxAge > 0 {
+		ctx.Response.Header.Set(fasthttp.HeaderAccessControlMaxAge, strconv.Itoa(int(cfg.MaxAge.Seconds())))
+	}
+	ctx.SetStatusCode(fasthttp.StatusNoContent)
+}
+
+func (h *Handler) corsOriginAllowed(origin string) bool {
+	for _, allowed := range h.config().CORS.AllowedOrigins {
+		if allowed == corsAnyOrigin || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+
+	return false
+}
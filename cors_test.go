@@ -0,0 +1,104 @@
+package main
+
+import (
+	"io"
+	"testing"
+
+	"github.com/kataras/golog"
+	"github.com/valyala/fasthttp"
+
+	"github.com/mymmrac/syodo-telegram-bot/config"
+	"github.com/mymmrac/syodo-telegram-bot/logger"
+)
+
+func TestCORS(t *testing.T) {
+	h := &Handler{
+		log: logger.NewLog(golog.New()),
+	}
+	h.cfg.Store(&config.Config{CORS: config.CORS{
+		AllowedOrigins: []string{"https://telegrambot.syodo.com.ua"},
+		AllowedMethods: []string{fasthttp.MethodPost},
+		AllowedHeaders: []string{"Content-Type"},
+	}})
+	h.log.(*logger.Log).SetOutput(io.Discard)
+
+	request := func(method, origin string, headers map[string]string) *fasthttp.RequestCtx {
+		ctx := &fasthttp.RequestCtx{}
+		ctx.Request.Header.SetMethod(method)
+		if origin != "" {
+			ctx.Request.Header.Set(fasthttp.HeaderOrigin, origin)
+		}
+		for key, value := range headers {
+			ctx.Request.Header.Set(key, value)
+		}
+		return ctx
+	}
+
+	handled := false
+	next := h.cors(func(ctx *fasthttp.RequestCtx) { handled = true })
+
+	ctx := request(fasthttp.MethodPost, "https://telegrambot.syodo.com.ua", nil)
+	next(ctx)
+	if !handled || string(ctx.Response.Header.Peek(fasthttp.HeaderAccessControlAllowOrigin)) == "" {
+		t.Error("expected allowed origin to be handled with CORS headers")
+	}
+
+	handled = false
+	ctx = request(fasthttp.MethodPost, "https://evil.example.com", nil)
+	next(ctx)
+	if handled || ctx.Response.StatusCode() != fasthttp.StatusForbidden {
+		t.Error("expected disallowed origin to be rejected")
+	}
+
+	handled = false
+	next(request(fasthttp.MethodPost, "", nil))
+	if !handled {
+		t.Error("expected request without origin to be handled")
+	}
+
+	tests := []struct {
+		name    string
+		origin  string
+		headers map[string]string
+		status  int
+	}{
+		{
+			name:   "allowed",
+			origin: "https://telegrambot.syodo.com.ua",
+			headers: map[string]string{
+				fasthttp.HeaderAccessControlRequestMethod:  fasthttp.MethodPost,
+				fasthttp.HeaderAccessControlRequestHeaders: "content-type",
+			},
+			status: fasthttp.StatusNoContent,
+		},
+		{
+			name:    "disallowed origin",
+			origin:  "https://evil.example.com",
+			headers: map[string]string{fasthttp.HeaderAccessControlRequestMethod: fasthttp.MethodPost},
+			status:  fasthttp.StatusForbidden,
+		},
+		{
+			name:    "disallowed method",
+			origin:  "https://telegrambot.syodo.com.ua",
+			headers: map[string]string{fasthttp.HeaderAccessControlRequestMethod: fasthttp.MethodDelete},
+			status:  fasthttp.StatusForbidden,
+		},
+		{
+			name:   "disallowed header",
+			origin: "https://telegrambot.syodo.com.ua",
+			headers: map[string]string{
+				fasthttp.HeaderAccessControlRequestMethod:  fasthttp.MethodPost,
+				fasthttp.HeaderAccessControlRequestHeaders: "X-Custom",
+			},
+			status: fasthttp.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		ctx = request(fasthttp.MethodOptions, tt.origin, tt.headers)
+		h.corsPreflight(ctx)
+		if ctx.Response.StatusCode() != tt.status {
+			t.Errorf("preflight %s: expected status %d, got %d", tt.name, tt.status, ctx.Response.StatusCode())
+		}
+	}
+}
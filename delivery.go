@@ -3,7 +3,10 @@ package main
 import (
 	"context"
 	"fmt"
+	"sync/atomic"
+	"time"
 
+	"github.com/valyala/fasthttp"
 	"googlemaps.github.io/maps"
 
 	"github.com/mymmrac/syodo-telegram-bot/config"
@@ -20,32 +23,60 @@ const (
 	ZoneRed    DeliveryZone = "red"
 )
 
+// zoneLabelTexts represents texts with invoice labels of delivery zones
+var zoneLabelTexts = map[DeliveryZone]string{
+	ZoneGreen:  "greenZoneLabel",
+	ZoneYellow: "yellowZoneLabel",
+	ZoneRed:    "redZoneLabel",
+}
+
+// googleMapsPingURL is requested without API key, so availability check doesn't use quota
+const googleMapsPingURL = "https://maps.googleapis.com/maps/api/geocode/json"
+
 // DeliveryStrategy represents model of calculation delivery zones by addresses
 type DeliveryStrategy struct {
-	cfg    *config.Config
-	log    logger.Logger
-	client *maps.Client
+	cfg     atomic.Pointer[config.Config]
+	log     logger.Logger
+	client  *maps.Client
+	metrics *Metrics
 }
 
 // NewDeliveryStrategy creates new DeliveryStrategy
-func NewDeliveryStrategy(cfg *config.Config, log logger.Logger) (*DeliveryStrategy, error) {
+func NewDeliveryStrategy(cfg *config.Config, log logger.Logger, metrics *Metrics) (*DeliveryStrategy, error) {
 	client, err := maps.NewClient(maps.WithAPIKey(cfg.App.GoogleMapsAPIKey))
 	if err != nil {
 		return nil, fmt.Errorf("create maps client: %w", err)
 	}
 
-	return &DeliveryStrategy{
-		cfg:    cfg,
-		log:    log,
-		client: client,
-	}, nil
+	s := &DeliveryStrategy{
+		log:     log,
+		client:  client,
+		metrics: metrics,
+	}
+	s.SetConfig(cfg)
+
+	return s, nil
+}
+
+// SetConfig replaces config used for following requests, used on reload, Google Maps API key is used only when
+// strategy is created
+func (s *DeliveryStrategy) SetConfig(cfg *config.Config) {
+	s.cfg.Store(cfg)
+}
+
+// config returns current config
+func (s *DeliveryStrategy) config() *config.Config {
+	return s.cfg.Load()
 }
 
 // CalculateLocation returns delivery location by its address
-func (s *DeliveryStrategy) CalculateLocation(order OrderRequest) (maps.LatLng, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), s.cfg.Settings.RequestTimeout)
+func (s *DeliveryStrategy) CalculateLocation(ctx context.Context, order OrderRequest) (maps.LatLng, error) {
+	log := logger.WithContext(s.log, ctx)
+
+	ctx, cancel := context.WithTimeout(ctx, s.config().Settings.RequestTimeout)
 	defer cancel()
 
+	start := time.Now()
 	results, err := s.client.Geocode(ctx, &maps.GeocodingRequest{
 		Components: map[maps.Component]string{
 			maps.ComponentCountry:  "ua",
@@ -56,7 +87,10 @@ func (s *DeliveryStrategy) CalculateLocation(order OrderRequest) (maps.LatLng, e
 		Region:   "ua",
 		Language: "uk",
 	})
+	duration := time.Since(start)
+	s.metrics.geocodeDuration.Observe(duration.Seconds())
 	if err != nil {
+		s.metrics.geocodeErrors.Inc()
 		return maps.LatLng{}, fmt.Errorf("geocode for %+v, error: %w", order, err)
 	}
 
@@ -67,12 +101,27 @@ func (s *DeliveryStrategy) CalculateLocation(order OrderRequest) (maps.LatLng, e
 	chosenResult := results[0]
 	location := chosenResult.Geometry.Location
 
-	s.log.Debugf("Calculate zone: chosen address for %+v was: location: %s, address: %s",
+	logger.WithFields(log, logger.Fields{"duration": duration.String()}).Debugf(
+		"Calculate zone: chosen address for %+v was: location: %s, address: %s",
 		order, location.String(), chosenResult.FormattedAddress)
 
 	return location, nil
 }
 
+// Ping checks that Google Maps API is reachable
+func (s *DeliveryStrategy) Ping() error {
+	statusCode, _, err := fasthttp.GetTimeout(nil, googleMapsPingURL, s.config().Health.MapsTimeout)
+	if err != nil {
+		return fmt.Errorf("call google maps: %w", err)
+	}
+
+	if statusCode >= fasthttp.StatusInternalServerError {
+		return fmt.Errorf("call google maps bad status: %d", statusCode)
+	}
+
+	return nil
+}
+
 //nolint:gomnd
 var approximateBounds = &maps.LatLngBounds{
 	NorthEast: maps.LatLng{
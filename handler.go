@@ -1,9 +1,12 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/fasthttp/router"
 	"github.com/mymmrac/memkey"
@@ -22,103 +25,445 @@ const (
 	orderKeyBound = 1_000_000
 )
 
+// ReloadFiles represents files that are read again on reload
+type ReloadFiles struct {
+	Config string
+	Text   string
+}
+
 // Handler represents update handler
 type Handler struct {
-	cfg        *config.Config
+	cfg        atomic.Pointer[config.Config]
+	files      ReloadFiles
 	log        logger.Logger
 	bot        *telego.Bot
 	bh         *th.BotHandler
 	rtr        *router.Router
-	data       TextData
+	adminRtr   *router.Router
+	data       atomic.Pointer[TextData]
+	reloadLock sync.Mutex
+	alerts     sync.Map
+	catalog    *Catalog
+	promotions Promotions
+	promoCodes *PromoCodes
 	orderStore *memkey.Store[string]
+	locales    *memkey.Store[int64]
 	delivery   *DeliveryStrategy
 	syodo      *SyodoService
+	schedule   atomic.Pointer[WorkingHours]
+	metrics    *Metrics
+	userLimits atomic.Pointer[RateLimiter[int64]]
+	ipLimits   atomic.Pointer[RateLimiter[string]]
+	idempotent *IdempotencyStore
 }
 
 // NewHandler creates new Handler
-func NewHandler(cfg *config.Config, log logger.Logger, bot *telego.Bot, bh *th.BotHandler, rtr *router.Router,
-	textData TextData, delivery *DeliveryStrategy,
+func NewHandler(cfg *config.Config, log logger.Logger, bot *telego.Bot, bh *th.BotHandler, rtr, adminRtr *router.Router,
+	textData *TextData, catalog *Catalog, promotions Promotions, promoCodes *PromoCodes, delivery *DeliveryStrategy,
+	syodo *SyodoService, schedule *WorkingHours, metrics *Metrics, files ReloadFiles,
 ) *Handler {
-	return &Handler{
-		cfg:        cfg,
+	h := &Handler{
+		files:      files,
 		log:        log,
 		bot:        bot,
 		bh:         bh,
 		rtr:        rtr,
-		data:       textData,
+		adminRtr:   adminRtr,
+		catalog:    catalog,
+		promotions: promotions,
+		promoCodes: promoCodes,
 		orderStore: &memkey.Store[string]{},
+		locales:    &memkey.Store[int64]{},
 		delivery:   delivery,
-		syodo:      NewSyodoService(cfg, log),
+		syodo:      syodo,
+		metrics:    metrics,
+		idempotent: NewIdempotencyStore(),
 	}
+	h.cfg.Store(cfg)
+	h.schedule.Store(schedule)
+	h.userLimits.Store(NewRateLimiter[int64](cfg.RateLimit.PerUser))
+	h.ipLimits.Store(NewRateLimiter[string](cfg.RateLimit.PerIP))
+	h.data.Store(textData)
+	metrics.RegisterOrderStoreSize(h.orderStore.Len)
+
+	return h
 }
 
 // RegisterHandlers registers all handlers in bot handler
 func (h *Handler) RegisterHandlers() {
-	err := h.bot.SetMyCommands(&telego.SetMyCommandsParams{
-		Commands: []telego.BotCommand{
-			{Command: "start", Description: h.data.Text("startDescription")},
-			{Command: "help", Description: h.data.Text("helpDescription")},
-		},
-	})
+	if err := h.registerCommands(); err != nil {
+		h.log.Fatal(err)
+	}
+
+	h.bh.Use(h.updateCorrelation)
+
+	h.bh.Handle(h.startCmd, th.CommandEqual("start"))
+	h.bh.Handle(h.helpCmd, th.CommandEqual("help"))
+	h.bh.Handle(h.languageCmd, th.CommandEqual("language"))
+	h.bh.Handle(h.languageSelected, th.CallbackDataPrefix(languageCallbackPrefix))
+	h.bh.Handle(h.closeCmd, th.CommandEqual("close"), h.isAdmin)
+	h.bh.Handle(h.openCmd, th.CommandEqual("open"), h.isAdmin)
+	h.bh.Handle(h.reloadCmd, th.CommandEqual("reload"), h.isAdmin)
+	h.bh.Handle(h.preCheckout, th.AnyPreCheckoutQuery())
+	h.bh.Handle(h.successPayment, th.SuccessPayment())
+	h.bh.Handle(h.unknown, th.AnyMessage())
+
+	h.rtr.POST("/order", h.requestCorrelation(h.cors(func(ctx *fasthttp.RequestCtx) {
+		h.logFor(ctx).Debugf("Received order request: `%s`", string(ctx.PostBody()))
+		h.orderHandler(ctx)
+	})))
+	h.rtr.GlobalOPTIONS = h.requestCorrelation(h.corsPreflight)
+
+	h.registerAdminHandlers()
+}
+
+// botCommands represents commands available to users, each command has description text named <command>Description
+var botCommands = []string{"start", "help", "language"}
+
+// registerCommands registers bot commands for each locale and menu button
+func (h *Handler) registerCommands() error {
+	textData := h.textData()
+
+	for _, locale := range textData.Locales() {
+		// Commands without language code are used for all users whose language has no dedicated commands
+		languageCode := locale
+		if locale == textData.DefaultLocale() {
+			languageCode = ""
+		}
+
+		commands := make([]telego.BotCommand, 0, len(botCommands))
+		for _, command := range botCommands {
+			description, err := textData.Text(locale, command+"Description")
+			if err != nil {
+				return fmt.Errorf("command %q description: %w", command, err)
+			}
+
+			commands = append(commands, telego.BotCommand{Command: command, Description: description})
+		}
+
+		err := h.bot.SetMyCommands(&telego.SetMyCommandsParams{
+			Commands:     commands,
+			LanguageCode: languageCode,
+		})
+		if err != nil {
+			return fmt.Errorf("set bot commands for %q: %w", locale, err)
+		}
+	}
+
+	menuButton, err := textData.Text(textData.DefaultLocale(), "menuButton")
 	if err != nil {
-		h.log.Fatalf("Set bot commands: %v", err)
+		return fmt.Errorf("menu button: %w", err)
 	}
 
 	err = h.bot.SetChatMenuButton(&telego.SetChatMenuButtonParams{
 		MenuButton: &telego.MenuButtonWebApp{
 			Type: telego.ButtonTypeWebApp,
-			Text: h.data.Text("menuButton"),
+			Text: menuButton,
 			WebApp: telego.WebAppInfo{
-				URL: h.cfg.App.WebAppURL,
+				URL: h.config().App.WebAppURL,
 			},
 		},
 	})
 	if err != nil {
-		h.log.Fatalf("Set bot menu button: %v", err)
+		return fmt.Errorf("set bot menu button: %w", err)
 	}
 
-	h.bh.HandleMessage(h.startCmd, th.CommandEqual("start"))
-	h.bh.HandleMessage(h.helpCmd, th.CommandEqual("help"))
-	h.bh.HandlePreCheckoutQuery(h.preCheckout)
-	h.bh.HandleMessage(h.successPayment, th.SuccessPayment())
-	h.bh.HandleMessage(h.unknown)
+	return nil
+}
 
-	h.rtr.POST("/order", func(ctx *fasthttp.RequestCtx) {
-		h.log.Debugf("Received order request: `%s`", string(ctx.PostBody()))
-		h.orderHandler(ctx)
-	})
+// updateCorrelation is a middleware that adds new correlation ID to context of each update
+func (h *Handler) updateCorrelation(next th.Handler) th.Handler {
+	return func(bot *telego.Bot, update telego.Update) {
+		ctx := logger.WithCorrelationID(update.Context(), logger.NewCorrelationID())
+		next(bot, update.WithContext(ctx))
+	}
+}
 
-	h.rtr.GET("/order", func(ctx *fasthttp.RequestCtx) {
-		//nolint:errcheck
-		_, _ = ctx.WriteString(strconv.Itoa(h.orderStore.Len()))
+// requestCorrelation is a middleware that adds new correlation ID to each request and returns it in response header
+func (h *Handler) requestCorrelation(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+	return func(ctx *fasthttp.RequestCtx) {
+		id := logger.NewCorrelationID()
+		ctx.SetUserValue(logger.CorrelationIDKey, id)
+		ctx.Response.Header.Set(correlationIDHeader, id)
+
+		start := time.Now()
+		next(ctx)
+
+		logger.WithFields(h.logFor(ctx), logger.Fields{
+			"path":     string(ctx.Path()),
+			"status":   ctx.Response.StatusCode(),
+			"duration": time.Since(start).String(),
+		}).Debug("Request handled")
+	}
+}
+
+// logFor returns logger that includes correlation ID from context in each log line
+func (h *Handler) logFor(ctx context.Context) logger.Logger {
+	return logger.WithContext(h.log, ctx)
+}
+
+// textData returns current text data
+func (h *Handler) textData() *TextData {
+	return h.data.Load()
+}
+
+// fallbackText is used when text can't be executed in any locale
+const fallbackText = "😔 Щось пішло не так / Something went wrong"
+
+// temp returns text in given locale with given data, text failures are reported to admins and fallback text is used
+// if there is no text to send
+func (h *Handler) temp(ctx context.Context, locale, key string, data any) string {
+	log := h.logFor(ctx)
+	text, err := h.textData().Temp(locale, key, data)
+	if err != nil {
+		log.Errorf("Text %q (%s): %s", key, locale, err)
+		h.alertAdmins("text:"+locale+":"+key, fmt.Sprintf("⚠️ Text %q (%s) failed: %s", key, locale, err))
+	}
+
+	if text == "" {
+		return fallbackText
+	}
+
+	return text
+}
+
+// text returns text in given locale with no data, see temp for failure handling
+func (h *Handler) text(ctx context.Context, locale, key string) string {
+	return h.temp(ctx, locale, key, nil)
+}
+
+// alertAdmins sends message to all admins, only first alert with the same key is sent until reload
+func (h *Handler) alertAdmins(key, message string) {
+	if _, alerted := h.alerts.LoadOrStore(key, struct{}{}); alerted {
+		return
+	}
+
+	for _, id := range h.config().App.AdminIDs {
+		_, err := h.bot.SendMessage(tu.Message(tu.ID(id), message))
+		if err != nil {
+			h.log.Errorf("Send alert to %d: %s", id, err)
+		}
+	}
+}
+
+// Reload reloads text data and config, current text data and config are kept if new ones fail to load or apply,
+// only settings read per request (schedule, CORS, rate limits, order settings, Syodo API and payment settings) are
+// applied, changes of servers, bot token, Google Maps API key and logging require restart
+func (h *Handler) Reload() error {
+	h.reloadLock.Lock()
+	defer h.reloadLock.Unlock()
+
+	cfg, err := config.LoadConfig(h.files.Config)
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+
+	schedule, err := NewWorkingHours(cfg.Schedule, h.syodo.timezone)
+	if err != nil {
+		return fmt.Errorf("init working hours: %w", err)
+	}
+
+	textData, err := LoadTextData(h.files.Text, h.syodo.timezone, h.catalog)
+	if err != nil {
+		return fmt.Errorf("load text data: %w", err)
+	}
+
+	oldTextData := h.data.Swap(textData)
+	if err = h.registerCommands(); err != nil {
+		h.data.Store(oldTextData)
+		if restoreErr := h.registerCommands(); restoreErr != nil {
+			h.log.Errorf("Restore commands: %s", restoreErr)
+		}
+
+		return fmt.Errorf("register commands: %w", err)
+	}
+
+	h.applyConfig(cfg, schedule)
+
+	h.alerts.Range(func(key, _ any) bool {
+		h.alerts.Delete(key)
+		return true
 	})
+
+	return nil
+}
+
+// applyConfig replaces config and schedule keeping ad-hoc closure, rate limiters are recreated only if their limits
+// changed, so current limits of users are kept
+func (h *Handler) applyConfig(cfg *config.Config, schedule *WorkingHours) {
+	if reason, closed := h.workingHours().Closure(); closed {
+		schedule.Close(reason)
+	}
+	h.schedule.Store(schedule)
+
+	oldCfg := h.config()
+	if cfg.RateLimit.PerUser != oldCfg.RateLimit.PerUser {
+		h.userLimits.Store(NewRateLimiter[int64](cfg.RateLimit.PerUser))
+	}
+	if cfg.RateLimit.PerIP != oldCfg.RateLimit.PerIP {
+		h.ipLimits.Store(NewRateLimiter[string](cfg.RateLimit.PerIP))
+	}
+
+	h.syodo.SetConfig(cfg)
+	h.delivery.SetConfig(cfg)
+	h.cfg.Store(cfg)
+}
+
+// config returns current config
+func (h *Handler) config() *config.Config {
+	return h.cfg.Load()
+}
+
+// workingHours returns current working hours
+func (h *Handler) workingHours() *WorkingHours {
+	return h.schedule.Load()
 }
 
 //nolint:funlen,gocognit,cyclop
 func (h *Handler) orderHandler(ctx *fasthttp.RequestCtx) {
+	log := h.logFor(ctx)
+	h.metrics.ordersReceived.Inc()
+
+	ip := h.remoteIP(ctx)
+	if ok, retryAfter := h.ipLimits.Load().Allow(ip, time.Now()); !ok {
+		log.Warnf("Order rate limit exceeded for IP %s, retry after %s", ip, retryAfter)
+		h.writeRateLimited(ctx, retryAfter)
+		return
+	}
+
 	data := ctx.PostBody()
 
 	var order OrderRequest
 	if err := json.Unmarshal(data, &order); err != nil {
-		h.log.Errorf("Unmarshal order request: %s", err)
+		log.Errorf("Unmarshal order request: %s", err)
+		h.metrics.orderFailed(failureBadRequest)
 		ctx.SetStatusCode(fasthttp.StatusBadRequest)
 		return
 	}
 
-	_, err := tu.ValidateWebAppData(h.bot.Token(), order.AppData)
+	appData, err := tu.ValidateWebAppData(h.bot.Token(), order.AppData)
+	if err != nil {
+		log.Errorf("Invalid web app data: %q", order.AppData)
+		h.metrics.orderFailed(failureAppData)
+		ctx.SetStatusCode(fasthttp.StatusForbidden)
+		return
+	}
+
+	user, err := webAppUser(appData)
+	if err != nil {
+		log.Errorf("Invalid web app user: %s", err)
+		h.metrics.orderFailed(failureAppData)
+		ctx.SetStatusCode(fasthttp.StatusForbidden)
+		return
+	}
+	locale := h.userLocale(&user)
+	log = logger.WithFields(log, logger.Fields{"userID": user.ID})
+
+	authDate, err := webAppAuthDate(appData)
 	if err != nil {
-		h.log.Errorf("Invalid web app data: %q", order.AppData)
+		log.Errorf("Invalid web app auth date: %s", err)
+		h.metrics.orderFailed(failureAppData)
+		ctx.SetStatusCode(fasthttp.StatusForbidden)
+		return
+	}
+	if webAppDataExpired(authDate, time.Now(), h.config().Settings.InitDataMaxAge) {
+		log.Errorf("Web app data expired, created %s ago", time.Since(authDate))
+		h.metrics.orderFailed(failureAppDataExpired)
 		ctx.SetStatusCode(fasthttp.StatusForbidden)
 		return
 	}
 
+	idempotencyKey, idempotencyTTL, err := h.idempotencyKey(ctx, user.ID, order)
+	if err != nil {
+		log.Errorf("Idempotency key: %s", err)
+		h.metrics.orderFailed(failureBadRequest)
+		ctx.SetStatusCode(fasthttp.StatusBadRequest)
+		return
+	}
+
+	var idempotentResp *idempotentResponse
+	if idempotencyKey != "" {
+		var duplicate bool
+		idempotentResp, duplicate = h.idempotent.Begin(idempotencyKey, idempotencyTTL, time.Now())
+		if duplicate {
+			log.Infof("Duplicate order request")
+			h.writeDuplicate(ctx, idempotentResp)
+			return
+		}
+		defer h.idempotent.Finish(idempotencyKey, idempotentResp, ctx)
+	}
+
+	if ok, retryAfter := h.userLimits.Load().Allow(user.ID, time.Now()); !ok {
+		log.Warnf("Order rate limit exceeded, retry after %s", retryAfter)
+		h.writeRateLimited(ctx, retryAfter)
+		return
+	}
+
 	if order.Name == "" || len(order.Phone) != 13 ||
-		(order.DeliveryType == deliveryTypeDelivery && (order.Address == "" || order.City == "")) {
-		h.log.Errorf("Bad order info: %+v", order)
+		(order.DeliveryType == deliveryTypeDelivery && (order.Address == "" || order.City == "")) ||
+		!validPayment(order) {
+		log.Errorf("Bad order info: %+v", order)
+		h.metrics.orderFailed(failureOrderInfo)
+		ctx.SetStatusCode(fasthttp.StatusBadRequest)
+		return
+	}
+
+	now := h.now()
+	scheduledAt, err := h.workingHours().ScheduledTime(order.DeliveryDate, order.DeliveryTime, now)
+	if err != nil {
+		log.Errorf("Bad scheduled time: %s", err)
+		h.metrics.orderFailed(failureScheduledTime)
+		ctx.SetStatusCode(fasthttp.StatusBadRequest)
+		return
+	}
+
+	closeReason, closed := h.workingHours().Closure()
+	if closed || (scheduledAt.IsZero() && !h.workingHours().IsOpen(now)) {
+		log.Errorf("Order while closed, reason: %q", closeReason)
+		h.metrics.orderFailed(failureClosed)
+
+		orderErr := orderError{
+			Error:  orderErrorClosed,
+			Reason: closeReason,
+		}
+		if nextOpening := h.workingHours().NextOpening(now); !nextOpening.IsZero() {
+			orderErr.NextOpening = &nextOpening
+		}
+
+		h.writeError(ctx, fasthttp.StatusServiceUnavailable, orderErr)
+		return
+	}
+
+	if err = h.promotions.Eligible(order.Promotion, order, now); err != nil {
+		log.Errorf("Promotion not eligible: %s", err)
+		h.metrics.orderFailed(failurePromotion)
 		ctx.SetStatusCode(fasthttp.StatusBadRequest)
 		return
 	}
 
+	discount := OrderDiscount{
+		Promotion: order.Promotion,
+	}
+	if order.PromoCode != "" {
+		if order.Promotion != "" {
+			log.Errorf("Promo code %q can't be used with promotion %q", order.PromoCode, order.Promotion)
+			h.metrics.orderFailed(failurePromoCode)
+			ctx.SetStatusCode(fasthttp.StatusBadRequest)
+			return
+		}
+
+		promoCode, promoErr := h.promoCodes.Validate(order.PromoCode, user.ID, now)
+		if promoErr != nil {
+			log.Errorf("Invalid promo code: %s", promoErr)
+			h.metrics.orderFailed(failurePromoCode)
+			ctx.SetStatusCode(fasthttp.StatusBadRequest)
+			return
+		}
+
+		discount.PromoCode = promoCode.Code
+		discount.PromoCodeDiscount = promoCode.Discount(productsSum(order.Products))
+	}
+
 	var (
 		price    PriceResponse
 		location maps.LatLng
@@ -126,181 +471,384 @@ func (h *Handler) orderHandler(ctx *fasthttp.RequestCtx) {
 
 	switch order.DeliveryType {
 	case deliveryTypeDelivery:
-		location, err = h.delivery.CalculateLocation(order)
+		location, err = h.delivery.CalculateLocation(ctx, order)
 		if err != nil {
 			break
 		}
 		order.Location = location
 
-		price, err = h.syodo.CalculatePriceDelivery(order.Products, location, order.Promotion)
+		price, err = h.syodo.CalculatePriceDelivery(ctx, order.Products, location, discount)
 	case "self_pickup_1", "self_pickup_2":
-		price, err = h.syodo.CalculatePriceSelfPickup(order.Products, order.Promotion)
+		price, err = h.syodo.CalculatePriceSelfPickup(ctx, order.Products, discount)
 	default:
-		h.log.Errorf("Unknown delivery type: %s", err)
+		log.Errorf("Unknown delivery type: %q", order.DeliveryType)
+		h.metrics.orderFailed(failureDeliveryType)
 		ctx.SetStatusCode(fasthttp.StatusBadRequest)
 		return
 	}
 
 	if err != nil {
-		h.log.Errorf("Calculate price: %s", err)
+		log.Errorf("Calculate price: %s", err)
+		h.metrics.orderFailed(failurePrice)
+		ctx.SetStatusCode(fasthttp.StatusInternalServerError)
+		return
+	}
+
+	// Otherwise user would pay full price and promo code would be used up, promo code is not reserved yet
+	if !discount.AppliedIn(price) {
+		log.Errorf("Promo code discount %d not applied in price, discount: %d", discount.PromoCodeDiscount,
+			price.Discount)
+		h.metrics.orderFailed(failurePromoCode)
+		ctx.SetStatusCode(fasthttp.StatusBadRequest)
+		return
+	}
+
+	if total := orderTotal(order.Products, price); !coversTotal(order.ChangeFrom, total) {
+		log.Errorf("Change from %d UAH is less than order total %d kopecks", order.ChangeFrom, total)
+		h.metrics.orderFailed(failureChangeFrom)
+		ctx.SetStatusCode(fasthttp.StatusBadRequest)
+		return
+	}
+
+	prices, err := h.constructPrices(ctx, locale, order, price, scheduledAt)
+	if err != nil {
+		log.Errorf("Construct prices: %s", err)
+		h.metrics.orderFailed(failureInvoicePrices)
 		ctx.SetStatusCode(fasthttp.StatusInternalServerError)
 		return
 	}
 
 	h.invalidateOldOrders()
-	orderKey := h.storeOrder(order, price.ServiceArea)
+	orderKey := h.storeOrder(OrderDetails{
+		CorrelationID:     logger.CorrelationID(ctx),
+		Request:           order,
+		ServiceArea:       price.ServiceArea,
+		ScheduledAt:       scheduledAt,
+		PromoCodeDiscount: discount.PromoCodeDiscount,
+		UserID:            user.ID,
+		// Web app is opened from private chat with bot, so all order messages are sent there
+		ChatID: user.ID,
+	})
+	log = logger.WithFields(log, logger.Fields{"orderID": orderKey})
+
+	if order.PromoCode != "" {
+		// Promo code is validated again, since other order could use it while price was calculated
+		if _, err = h.promoCodes.Reserve(order.PromoCode, user.ID, orderKey, now); err != nil {
+			log.Errorf("Reserve promo code: %s", err)
+			h.discardOrder(orderKey)
+			h.metrics.orderFailed(failurePromoCode)
+			ctx.SetStatusCode(fasthttp.StatusBadRequest)
+			return
+		}
+	}
+
+	if idempotentResp != nil {
+		h.idempotent.SetOrder(idempotentResp, orderKey)
+	}
+
+	if order.PaymentMethod == paymentMethodCash || order.PaymentMethod == paymentMethodCard {
+		h.confirmOfflineOrder(ctx, orderKey, locale)
+		return
+	}
 
 	link, err := h.bot.CreateInvoiceLink(&telego.CreateInvoiceLinkParams{
-		Title:         "Замовлення #" + orderKey,
-		Description:   h.data.Text("orderDescription"),
+		Title:         h.temp(ctx, locale, "invoiceTitle", orderKey),
+		Description:   h.text(ctx, locale, "orderDescription"),
 		Payload:       orderKey,
-		ProviderToken: h.cfg.App.ProviderToken,
+		ProviderToken: h.config().App.ProviderToken,
 		Currency:      currency,
-		Prices:        h.constructPrices(order, price),
+		Prices:        prices,
 	})
 	if err != nil || link == nil || *link == "" {
-		h.log.Errorf("Create invoice link: %q, %s", link, err)
+		log.Errorf("Create invoice link: %q, %s", link, err)
+		h.discardOrder(orderKey)
+		h.metrics.orderFailed(failureInvoiceCreated)
 		ctx.SetStatusCode(fasthttp.StatusInternalServerError)
 		return
 	}
 
+	h.metrics.invoicesCreated.Inc()
+
 	//nolint:errcheck
 	_, _ = ctx.WriteString(*link)
 	ctx.SetStatusCode(fasthttp.StatusOK)
 }
 
-func (h *Handler) constructPrices(order OrderRequest, price PriceResponse) []telego.LabeledPrice {
+func validPayment(order OrderRequest) bool {
+	switch order.PaymentMethod {
+	case "", paymentMethodOnline, paymentMethodCard:
+		return order.ChangeFrom == 0
+	case paymentMethodCash:
+		return order.ChangeFrom >= 0
+	default:
+		return false
+	}
+}
+
+// offlineOrderResponse represents response to web app for orders paid on delivery, orders paid online get invoice link
+// as plain text instead
+type offlineOrderResponse struct {
+	OrderID string `json:"orderID"`
+}
+
+// confirmOfflineOrder registers order paid on delivery in Syodo and confirms it in chat, invoice is not created
+func (h *Handler) confirmOfflineOrder(ctx *fasthttp.RequestCtx, orderKey, locale string) {
+	log := h.logFor(ctx)
+	order, ok := h.getOrder(orderKey)
+	if !ok {
+		log.Errorf("Order not found: %s", orderKey)
+		ctx.SetStatusCode(fasthttp.StatusInternalServerError)
+		return
+	}
+
+	if err := h.syodo.Checkout(ctx, &order); err != nil {
+		log.Errorf("Checkout: %s", err)
+		h.discardOrder(orderKey)
+		ctx.SetStatusCode(fasthttp.StatusInternalServerError)
+		return
+	}
+	log.Debugf("Order checkout: %+v", order)
+
+	h.completeOrder(ctx, order)
+	h.metrics.orderPaid(order.Request.PaymentMethod, order.TotalAmount)
+
+	_, err := h.bot.SendMessage(tu.Message(tu.ID(order.ChatID), h.temp(ctx, locale, "orderConfirmed", order)).
+		WithParseMode(telego.ModeHTML))
+	if err != nil {
+		// Order is already registered, so only logging error
+		log.Errorf("Send order confirmed message: %s", err)
+	}
+
+	h.writeJSON(ctx, offlineOrderResponse{OrderID: order.OrderID})
+}
+
+const orderErrorClosed = "closed"
+
+// orderError represents error details returned to web app
+type orderError struct {
+	Error       string     `json:"error"`
+	Reason      string     `json:"reason,omitempty"`
+	NextOpening *time.Time `json:"nextOpening,omitempty"`
+	// RetryAfter represents number of seconds after which rate limited request can be retried
+	RetryAfter int `json:"retryAfter,omitempty"`
+}
+
+func (h *Handler) writeError(ctx *fasthttp.RequestCtx, statusCode int, orderErr orderError) {
+	log := h.logFor(ctx)
+	ctx.SetStatusCode(statusCode)
+	ctx.SetContentType(contentTypeJSON)
+
+	if err := json.NewEncoder(ctx).Encode(orderErr); err != nil {
+		log.Errorf("Write order error: %s", err)
+	}
+}
+
+// constructPrices returns invoice prices of the order, error is returned if delivery zone is unknown
+func (h *Handler) constructPrices(
+	ctx context.Context, locale string, order OrderRequest, price PriceResponse, scheduledAt time.Time,
+) ([]telego.LabeledPrice, error) {
 	prices := make([]telego.LabeledPrice, 0, len(order.Products))
 	for _, p := range order.Products {
 		prices = append(prices, telego.LabeledPrice{
-			Label:  fmt.Sprintf("%s %d ✕ %s", emojiByCategoryID(p.CategoryID), p.Amount, p.Title),
+			Label:  fmt.Sprintf("%s %d ✕ %s", h.catalog.Emoji(p.CategoryID), p.Amount, p.Title),
 			Amount: p.Amount * p.Price,
 		})
 	}
 
 	if order.CutleryCount > 0 {
-		prices = append(prices, tu.LabeledPrice(fmt.Sprintf("🥢 %d ✕ Прибори", order.CutleryCount), 0))
+		prices = append(prices, tu.LabeledPrice(h.temp(ctx, locale, "cutleryLabel", order.CutleryCount), 0))
 	}
 	if order.TrainingCutleryCount > 0 {
-		prices = append(prices, tu.LabeledPrice(fmt.Sprintf("🥢 %d ✕ Навчальні прибори", order.TrainingCutleryCount), 0))
+		prices = append(prices, tu.LabeledPrice(
+			h.temp(ctx, locale, "trainingCutleryLabel", order.TrainingCutleryCount), 0))
 	}
 	if !order.NoNapkins {
-		prices = append(prices, tu.LabeledPrice("🧻 Серветки", 0))
+		prices = append(prices, tu.LabeledPrice(h.text(ctx, locale, "napkinsLabel"), 0))
+	}
+
+	if !scheduledAt.IsZero() {
+		prices = append(prices, tu.LabeledPrice(h.temp(ctx, locale, "scheduledLabel", scheduledAt), 0))
 	}
 
 	if price.Delivery != 0 {
 		if order.DeliveryType == deliveryTypeDelivery {
-			prices = append(prices, tu.LabeledPrice(h.labelByZone(price.ServiceArea), price.Delivery))
+			labelText, ok := zoneLabelTexts[price.ServiceArea]
+			if !ok {
+				return nil, fmt.Errorf("unknown zone %q", price.ServiceArea)
+			}
+			prices = append(prices, tu.LabeledPrice(h.text(ctx, locale, labelText), price.Delivery))
 		} else {
-			prices = append(prices, tu.LabeledPrice("👋 Самовивіз", price.Delivery))
+			prices = append(prices, tu.LabeledPrice(h.text(ctx, locale, "selfPickupLabel"), price.Delivery))
 		}
 	}
 
-	switch order.Promotion {
-	case promo4Plus1:
-		prices = append(prices, tu.LabeledPrice("🎟 Акція 4+1", -price.Discount))
+	if promotion, ok := h.promotions[order.Promotion]; ok {
+		prices = append(prices, tu.LabeledPrice(promotion.Emoji+" "+promotion.LocalizedLabel(locale), -price.Discount))
+	}
+
+	// Discount is taken from Syodo prices, so invoice amount matches amount registered in Syodo
+	if promoCode, ok := h.promoCodes.Get(order.PromoCode); ok && price.Discount != 0 {
+		prices = append(prices, tu.LabeledPrice("🏷 "+promoCode.LocalizedLabel(locale), -price.Discount))
 	}
 
-	return prices
+	return prices, nil
 }
 
-func emojiByCategoryID(id string) string {
-	switch id {
-	case "13": // Суші
-		return "🍣"
-	case "7", "8", "14": // Роли, Сети, Без лактози
-		return "🍱"
-	case "9": // Напої
-		return "🥤"
-	case "10": // Соуси
-		return "🍥"
-	case "11": // Десерти
-		return "🍡"
-	default:
-		return "🍱"
+// isAdmin checks if message was sent by admin
+func (h *Handler) isAdmin(update telego.Update) bool {
+	if update.Message == nil || update.Message.From == nil {
+		return false
+	}
+
+	for _, id := range h.config().App.AdminIDs {
+		if id == update.Message.From.ID {
+			return true
+		}
 	}
+
+	return false
 }
 
-func (h *Handler) labelByZone(zone DeliveryZone) string {
-	switch zone {
-	case ZoneGreen:
-		return "🛵 Доставка у зелену зону"
-	case ZoneYellow:
-		return "🛵 Доставка у жовту зону"
-	case ZoneRed:
-		return "🛵 Доставка у червону зону"
-	default:
-		// No shipping option
-		h.log.Errorf("Unknown zone: %q", zone)
-		return "<UNKNOWN>"
+// userLocale returns locale selected by user or matching user's language
+func (h *Handler) userLocale(user *telego.User) string {
+	if user == nil {
+		return h.textData().DefaultLocale()
+	}
+
+	if locale, ok := memkey.Get[string](h.locales, user.ID); ok {
+		return locale
+	}
+
+	return h.textData().Locale(user.LanguageCode)
+}
+
+// orderContext returns context with correlation ID of the order, so logs of payment are tied to order request, update's
+// correlation ID is logged to keep the link with previous log lines
+func (h *Handler) orderContext(ctx context.Context, order OrderDetails) context.Context {
+	if order.CorrelationID == "" {
+		return ctx
 	}
+
+	h.logFor(ctx).Debugf("Continue order %s with correlation ID: %s", order.OrderID, order.CorrelationID)
+	return logger.WithCorrelationID(ctx, order.CorrelationID)
 }
 
-func (h *Handler) preCheckout(bot *telego.Bot, query telego.PreCheckoutQuery) {
+// now returns current time in Syodo timezone
+func (h *Handler) now() time.Time {
+	return time.Now().In(h.syodo.timezone)
+}
+
+func (h *Handler) preCheckout(bot *telego.Bot, update telego.Update) {
+	ctx := update.Context()
+	query := *update.PreCheckoutQuery
+	log := h.logFor(ctx)
+	locale := h.userLocale(&query.From)
+
 	order, ok := h.getOrder(query.InvoicePayload)
 	if !ok {
-		h.log.Errorf("Order not found: %s", query.InvoicePayload)
-		h.failPreCheckout(query.ID, h.data.Text("orderNotFoundError"))
+		log.Errorf("Order not found: %s", query.InvoicePayload)
+		h.failPreCheckout(ctx, query.ID, h.text(ctx, locale, "orderNotFoundError"))
 		return
 	}
 
-	if err := h.syodo.Checkout(&order); err != nil {
-		h.log.Errorf("Checkout: %s", err)
-		h.failPreCheckout(query.ID, h.data.Text("orderCheckoutError"))
+	ctx = h.orderContext(ctx, order)
+	log = logger.WithFields(h.logFor(ctx), logger.Fields{"orderID": order.OrderID, "userID": query.From.ID})
+
+	if !isOrderOwner(order, &query.From) {
+		// Responding as if order doesn't exist to not disclose orders of other users
+		log.Errorf("Pre checkout by user %d, but order created by user %d", query.From.ID, order.UserID)
+		h.failPreCheckout(ctx, query.ID, h.text(ctx, locale, "orderNotFoundError"))
+		return
+	}
+
+	if err := h.syodo.Checkout(ctx, &order); err != nil {
+		log.Errorf("Checkout: %s", err)
+		h.failPreCheckout(ctx, query.ID, h.text(ctx, locale, "orderCheckoutError"))
 		return
 	}
-	h.log.Debugf("Order checkout: %+v", order)
+	log.Debugf("Order checkout: %+v", order)
 
 	h.updateOrder(order)
 
+	h.metrics.preCheckout(true)
 	err := bot.AnswerPreCheckoutQuery(tu.PreCheckoutQuery(query.ID, true))
 	if err != nil {
-		h.log.Errorf("Answer pre checkout: %s", err)
+		log.Errorf("Answer pre checkout: %s", err)
 		return
 	}
 }
 
-func (h *Handler) failPreCheckout(queryID, failureReason string) {
+func (h *Handler) failPreCheckout(ctx context.Context, queryID, failureReason string) {
+	h.metrics.preCheckout(false)
 	err := h.bot.AnswerPreCheckoutQuery(tu.PreCheckoutQuery(queryID, false).WithErrorMessage(failureReason))
 	if err != nil {
-		h.log.Errorf("Answer pre checkout (failure): %s", err)
+		h.logFor(ctx).Errorf("Answer pre checkout (failure): %s", err)
 		return
 	}
 }
 
-func (h *Handler) successPayment(bot *telego.Bot, message telego.Message) {
+func (h *Handler) successPayment(bot *telego.Bot, update telego.Update) {
+	ctx := update.Context()
+	message := *update.Message
+	log := h.logFor(ctx)
 	chatID := message.Chat.ID
 	payment := message.SuccessfulPayment
+	locale := h.userLocale(message.From)
 
 	order, ok := h.getOrder(payment.InvoicePayload)
 	if !ok {
-		h.log.Errorf("Order not found: %s", payment.InvoicePayload)
+		log.Errorf("Order not found: %s", payment.InvoicePayload)
 
-		_, err := bot.SendMessage(tu.Message(tu.ID(chatID), h.data.Text("successPaymentOrderNotFoundError")))
+		_, err := bot.SendMessage(tu.Message(tu.ID(chatID), h.text(ctx, locale, "successPaymentOrderNotFoundError")))
 		if err != nil {
-			h.log.Errorf("Send success payment error message: %s", err)
+			log.Errorf("Send success payment error message: %s", err)
 			return
 		}
 		return
 	}
 
-	if err := h.syodo.SuccessPayment(payment, order.ExternalOrderID); err != nil {
-		h.log.Errorf("Success payment: %s", err)
+	var payerID int64
+	if message.From != nil {
+		payerID = message.From.ID
+	}
+
+	ctx = h.orderContext(ctx, order)
+	log = logger.WithFields(h.logFor(ctx), logger.Fields{"orderID": order.OrderID, "userID": payerID})
+
+	if !isOrderOwner(order, message.From) {
+		// Should not happen since pre checkout rejects other users, but payment is already made, so admins should
+		// resolve it manually
+		log.Errorf("Payment by user %d, but order created by user %d", payerID, order.UserID)
+		h.alertAdmins("payment-user:"+order.OrderID, fmt.Sprintf(
+			"⚠️ Order %s created by user %d was paid by user %d, payment: %s",
+			order.OrderID, order.UserID, payerID, payment.TelegramPaymentChargeID))
+
+		_, err := bot.SendMessage(tu.Message(tu.ID(chatID), h.text(ctx, locale, "successPaymentOrderFailedError")))
+		if err != nil {
+			log.Errorf("Send success payment error message: %s", err)
+		}
+		return
+	}
+
+	if err := h.syodo.SuccessPayment(ctx, payment, order.ExternalOrderID); err != nil {
+		log.Errorf("Success payment: %s", err)
 
-		_, err = bot.SendMessage(tu.Message(tu.ID(chatID), h.data.Text("successPaymentOrderFailedError")))
+		_, err = bot.SendMessage(tu.Message(tu.ID(chatID), h.text(ctx, locale, "successPaymentOrderFailedError")))
 		if err != nil {
-			h.log.Errorf("Send success payment error message: %s", err)
+			log.Errorf("Send success payment error message: %s", err)
 			return
 		}
 		return
 	}
 
-	h.orderStore.Delete(payment.InvoicePayload)
+	h.completeOrder(ctx, order)
+	h.idempotent.Forget(order.OrderID)
+	h.metrics.orderPaid(order.Request.PaymentMethod, float64(payment.TotalAmount)/priceMultiplier)
 
-	_, err := bot.SendMessage(tu.Message(tu.ID(chatID), h.data.Temp("successPayment", order)).
+	_, err := bot.SendMessage(tu.Message(tu.ID(chatID), h.temp(ctx, locale, "successPayment", order)).
 		WithParseMode(telego.ModeHTML))
 	if err != nil {
-		h.log.Errorf("Send success payment message: %s", err)
+		log.Errorf("Send success payment message: %s", err)
 		return
 	}
 }
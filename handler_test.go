@@ -0,0 +1,157 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kataras/golog"
+	"github.com/mymmrac/memkey"
+	"github.com/valyala/fasthttp"
+
+	"github.com/mymmrac/syodo-telegram-bot/config"
+	"github.com/mymmrac/syodo-telegram-bot/logger"
+)
+
+func TestApplyConfig(t *testing.T) {
+	scheduleCfg := config.Schedule{OpenTime: "10:00", CloseTime: "22:00"}
+	cfg := &config.Config{
+		Schedule: scheduleCfg,
+		RateLimit: config.RateLimit{
+			PerUser: config.Limit{Interval: time.Second, Burst: 1},
+			PerIP:   config.Limit{Interval: time.Second, Burst: 1},
+		},
+		App: config.App{GoogleMapsAPIKey: "key"},
+	}
+
+	schedule, err := NewWorkingHours(scheduleCfg, time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	schedule.Close("holiday")
+
+	delivery, err := NewDeliveryStrategy(cfg, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := &Handler{
+		syodo:    NewSyodoService(cfg, nil, nil),
+		delivery: delivery,
+	}
+	h.cfg.Store(cfg)
+	h.schedule.Store(schedule)
+	h.userLimits.Store(NewRateLimiter[int64](cfg.RateLimit.PerUser))
+	h.ipLimits.Store(NewRateLimiter[string](cfg.RateLimit.PerIP))
+	userLimits := h.userLimits.Load()
+
+	newCfg := *cfg
+	newCfg.Schedule.CloseTime = "23:00"
+	newCfg.RateLimit.PerIP = config.Limit{}
+
+	newSchedule, err := NewWorkingHours(newCfg.Schedule, time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.applyConfig(&newCfg, newSchedule)
+
+	if h.config().Schedule.CloseTime != "23:00" {
+		t.Error("expected new config to be applied")
+	}
+	if h.syodo.config() != &newCfg || h.delivery.config() != &newCfg {
+		t.Error("expected new config to be applied to Syodo and delivery")
+	}
+	if reason, closed := h.workingHours().Closure(); !closed || reason != "holiday" {
+		t.Error("expected closure to be kept after reload")
+	}
+	if h.userLimits.Load() != userLimits {
+		t.Error("expected unchanged user limits to be kept")
+	}
+	if h.ipLimits.Load() != nil {
+		t.Error("expected disabled IP limits to be applied")
+	}
+}
+
+func TestCheckOrderStore(t *testing.T) {
+	h := &Handler{orderStore: &memkey.Store[string]{}}
+	h.storeOrder(OrderDetails{})
+
+	if err := h.checkOrderStore(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if size := h.orderStore.Len(); size != 1 {
+		t.Errorf("expected store to be unchanged, got %d values", size)
+	}
+
+	memkey.Set(h.orderStore, "broken", OrderDetails{OrderID: "000001"})
+	if err := h.checkOrderStore(); err == nil {
+		t.Error("expected error for order stored under wrong key")
+	}
+
+	h.orderStore.Delete("broken")
+	memkey.Set(h.orderStore, "000002", "not an order")
+	if err := h.checkOrderStore(); err == nil {
+		t.Error("expected error for value that is not an order")
+	}
+}
+
+func TestValidPayment(t *testing.T) {
+	tests := []struct {
+		name  string
+		order OrderRequest
+		total int
+		valid bool
+	}{
+		{name: "default", order: OrderRequest{}, total: 50000, valid: true},
+		{name: "online", order: OrderRequest{PaymentMethod: paymentMethodOnline}, total: 50000, valid: true},
+		{name: "card", order: OrderRequest{PaymentMethod: paymentMethodCard}, total: 50000, valid: true},
+		{name: "cash", order: OrderRequest{PaymentMethod: paymentMethodCash}, total: 50000, valid: true},
+		{
+			name:  "cash_change",
+			order: OrderRequest{PaymentMethod: paymentMethodCash, ChangeFrom: 1000},
+			total: 50000,
+			valid: true,
+		},
+		{
+			name:  "cash_change_exact",
+			order: OrderRequest{PaymentMethod: paymentMethodCash, ChangeFrom: 500},
+			total: 50000,
+			valid: true,
+		},
+		{
+			name:  "cash_change_below_total",
+			order: OrderRequest{PaymentMethod: paymentMethodCash, ChangeFrom: 200},
+			total: 50000,
+		},
+		{name: "cash_negative_change", order: OrderRequest{PaymentMethod: paymentMethodCash, ChangeFrom: -1}},
+		{name: "card_change", order: OrderRequest{PaymentMethod: paymentMethodCard, ChangeFrom: 1000}},
+		{name: "online_change", order: OrderRequest{PaymentMethod: paymentMethodOnline, ChangeFrom: 1000}},
+		{name: "unknown", order: OrderRequest{PaymentMethod: "crypto"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			valid := validPayment(tt.order) && coversTotal(tt.order.ChangeFrom, tt.total)
+			if valid != tt.valid {
+				t.Errorf("expected valid: %t, got: %t", tt.valid, valid)
+			}
+		})
+	}
+}
+
+func TestOfflineOrderResponse(t *testing.T) {
+	h := &Handler{log: logger.NewLog(golog.New())}
+
+	ctx := &fasthttp.RequestCtx{}
+	h.writeJSON(ctx, offlineOrderResponse{OrderID: "000001"})
+
+	if ctx.Response.StatusCode() != fasthttp.StatusOK {
+		t.Errorf("unexpected status: %d", ctx.Response.StatusCode())
+	}
+	if contentType := string(ctx.Response.Header.ContentType()); contentType != contentTypeJSON {
+		t.Errorf("unexpected content type: %q", contentType)
+	}
+	if body := strings.TrimSpace(string(ctx.Response.Body())); body != `{"orderID":"000001"}` {
+		t.Errorf("unexpected body: %s", body)
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mymmrac/memkey"
+	"github.com/valyala/fasthttp"
+
+	"github.com/mymmrac/syodo-telegram-bot/logger"
+)
+
+const (
+	healthStatusOK   = "ok"
+	healthStatusFail = "fail"
+)
+
+// healthCheck represents result of single readiness check
+type healthCheck struct {
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+// healthResponse represents response of health and readiness endpoints
+type healthResponse struct {
+	Status string                 `json:"status"`
+	Checks map[string]healthCheck `json:"checks,omitempty"`
+}
+
+// healthz reports that process is alive
+func (h *Handler) healthz(ctx *fasthttp.RequestCtx) {
+	h.writeHealth(ctx, healthResponse{Status: healthStatusOK})
+}
+
+// readyz reports if all dependencies required to process orders are available
+func (h *Handler) readyz(ctx *fasthttp.RequestCtx) {
+	log := h.logFor(ctx)
+	checks := map[string]func() error{
+		"syodo":      h.syodo.Ping,
+		"googleMaps": h.delivery.Ping,
+		"orderStore": h.checkOrderStore,
+		"textData":   h.checkTextData,
+	}
+
+	resp := healthResponse{
+		Status: healthStatusOK,
+		Checks: make(map[string]healthCheck, len(checks)),
+	}
+	respLock := sync.Mutex{}
+
+	wg := sync.WaitGroup{}
+	wg.Add(len(checks))
+	for name, check := range checks {
+		go func(name string, check func() error) {
+			defer wg.Done()
+
+			start := time.Now()
+			err := check()
+
+			result := healthCheck{
+				Status:   healthStatusOK,
+				Duration: time.Since(start).String(),
+			}
+			if err != nil {
+				log.Errorf("Readiness check %q: %s", name, err)
+				result.Status = healthStatusFail
+				result.Error = err.Error()
+			}
+
+			respLock.Lock()
+			defer respLock.Unlock()
+
+			resp.Checks[name] = result
+			if err != nil {
+				resp.Status = healthStatusFail
+			}
+		}(name, check)
+	}
+	wg.Wait()
+
+	h.writeHealth(ctx, resp)
+}
+
+func (h *Handler) writeHealth(ctx *fasthttp.RequestCtx, resp healthResponse) {
+	log := h.logFor(ctx)
+	if resp.Status == healthStatusOK {
+		ctx.SetStatusCode(fasthttp.StatusOK)
+	} else {
+		ctx.SetStatusCode(fasthttp.StatusServiceUnavailable)
+	}
+	ctx.SetContentType(contentTypeJSON)
+
+	if err := json.NewEncoder(ctx).Encode(resp); err != nil {
+		log.Errorf("Write health response: %s", err)
+	}
+}
+
+// healthProbePrefix is prefix of keys written by order store check, it can't collide with digits only order IDs
+const healthProbePrefix = "health-probe:"
+
+// healthProbe represents value written by order store check
+type healthProbe struct {
+	CheckedAt time.Time
+}
+
+// checkOrderStore checks that order store is writable and that all stored values are orders stored under their IDs,
+// each check writes and deletes its own probe key, so concurrent checks don't interfere
+func (h *Handler) checkOrderStore() error {
+	probeKey := healthProbePrefix + logger.NewCorrelationID()
+	probe := healthProbe{CheckedAt: time.Now()}
+	memkey.Set(h.orderStore, probeKey, probe)
+	defer h.orderStore.Delete(probeKey)
+
+	if stored, ok := memkey.Get[healthProbe](h.orderStore, probeKey); !ok || !stored.CheckedAt.Equal(probe.CheckedAt) {
+		return errors.New("written probe can't be read")
+	}
+
+	for _, e := range h.orderStore.Entries() {
+		if strings.HasPrefix(e.Key, healthProbePrefix) {
+			continue
+		}
+
+		order, ok := e.Value.(OrderDetails)
+		if !ok {
+			return fmt.Errorf("value stored under key %q is not an order", e.Key)
+		}
+		if e.Key != order.OrderID {
+			return fmt.Errorf("order %q stored under key %q", order.OrderID, e.Key)
+		}
+	}
+
+	return nil
+}
+
+// checkTextData checks that text data is loaded
+func (h *Handler) checkTextData() error {
+	textData := h.textData()
+	if textData == nil || len(textData.Locales()) == 0 {
+		return errors.New("no text data loaded")
+	}
+
+	return nil
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/valyala/fasthttp"
+)
+
+const (
+	idempotencyKeyHeader    = "Idempotency-Key"
+	idempotencyKeyMaxLength = 128
+
+	// derivedKeyType marks keys derived from order content, it follows user ID in the key
+	derivedKeyType = "order"
+)
+
+// idempotentResponse represents response of order request that is replayed for duplicate requests, done is closed
+// when response is recorded
+type idempotentResponse struct {
+	done      chan struct{}
+	expiresAt time.Time
+
+	orderID     string
+	statusCode  int
+	contentType string
+	body        []byte
+}
+
+// IdempotencyStore represents responses of order requests by idempotency key
+type IdempotencyStore struct {
+	lock      sync.Mutex
+	responses map[string]*idempotentResponse
+}
+
+// NewIdempotencyStore creates new IdempotencyStore
+func NewIdempotencyStore() *IdempotencyStore {
+	return &IdempotencyStore{
+		responses: make(map[string]*idempotentResponse),
+	}
+}
+
+// Begin returns response for key, if there is no response yet new pending response is created and duplicate is false,
+// caller is responsible to finish it
+func (s *IdempotencyStore) Begin(key string, ttl time.Duration, now time.Time) (*idempotentResponse, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for k, resp := range s.responses {
+		if now.After(resp.expiresAt) {
+			delete(s.responses, k)
+		}
+	}
+
+	if resp, ok := s.responses[key]; ok {
+		return resp, true
+	}
+
+	resp := &idempotentResponse{
+		done:      make(chan struct{}),
+		expiresAt: now.Add(ttl),
+	}
+	s.responses[key] = resp
+
+	return resp, false
+}
+
+// SetOrder binds response to order, so it can be forgotten when order is paid
+func (s *IdempotencyStore) SetOrder(resp *idempotentResponse, orderID string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	resp.orderID = orderID
+}
+
+// Finish records response, only successful responses are kept so failed requests can be retried
+func (s *IdempotencyStore) Finish(key string, resp *idempotentResponse, ctx *fasthttp.RequestCtx) {
+	resp.statusCode = ctx.Response.StatusCode()
+	resp.contentType = string(ctx.Response.Header.ContentType())
+	resp.body = append([]byte(nil), ctx.Response.Body()...)
+
+	if resp.statusCode != fasthttp.StatusOK {
+		s.lock.Lock()
+		if s.responses[key] == resp {
+			delete(s.responses, key)
+		}
+		s.lock.Unlock()
+	}
+
+	close(resp.done)
+}
+
+// Forget removes responses of order stored under keys derived from order content, so paid invoice link is not
+// returned when the same order is placed again, responses stored under client keys are kept until they expire
+func (s *IdempotencyStore) Forget(orderID string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for key, resp := range s.responses {
+		if resp.orderID == orderID && isDerivedKey(key) {
+			delete(s.responses, key)
+		}
+	}
+}
+
+// isDerivedKey reports if key is derived from order content, client keys are not matched even if they contain key
+// type of derived keys
+func isDerivedKey(key string) bool {
+	_, keyWithoutUser, _ := strings.Cut(key, ":")
+	return strings.HasPrefix(keyWithoutUser, derivedKeyType+":")
+}
+
+// idempotencyKey returns key of order request, key provided by client is valid as long as order, otherwise key is
+// derived from order content and valid only within idempotency window, empty key disables idempotency
+func (h *Handler) idempotencyKey(
+	ctx *fasthttp.RequestCtx, userID int64, order OrderRequest,
+) (string, time.Duration, error) {
+	if clientKey := string(ctx.Request.Header.Peek(idempotencyKeyHeader)); clientKey != "" {
+		if len(clientKey) > idempotencyKeyMaxLength {
+			return "", 0, fmt.Errorf("idempotency key too long: %d", len(clientKey))
+		}
+		return fmt.Sprintf("%d:key:%s", userID, clientKey), h.config().Settings.OrderTTL, nil
+	}
+
+	if h.config().Settings.IdempotencyWindow == 0 {
+		return "", 0, nil
+	}
+
+	// App data is different each time web app is opened, so it's not part of order content
+	order.AppData = ""
+	data, err := json.Marshal(order)
+	if err != nil {
+		return "", 0, fmt.Errorf("marshal order: %w", err)
+	}
+	hash := sha256.Sum256(data)
+
+	key := fmt.Sprintf("%d:%s:%s", userID, derivedKeyType, hex.EncodeToString(hash[:]))
+	return key, h.config().Settings.IdempotencyWindow, nil
+}
+
+// writeDuplicate replays response of original request, waiting for it if it's still in progress
+func (h *Handler) writeDuplicate(ctx *fasthttp.RequestCtx, resp *idempotentResponse) {
+	h.metrics.duplicateOrders.Inc()
+
+	select {
+	case <-resp.done:
+	case <-time.After(h.config().Settings.RequestTimeout):
+		h.logFor(ctx).Errorf("Original order request still in progress")
+		ctx.SetStatusCode(fasthttp.StatusConflict)
+		return
+	}
+
+	ctx.SetStatusCode(resp.statusCode)
+	ctx.SetContentType(resp.contentType)
+	ctx.SetBody(resp.body)
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/mymmrac/syodo-telegram-bot/config"
+)
+
+func TestIdempotencyStore(t *testing.T) {
+	store := NewIdempotencyStore()
+	now := time.Date(2023, 3, 18, 12, 0, 0, 0, time.UTC)
+
+	resp, duplicate := store.Begin("key", time.Minute, now)
+	if duplicate {
+		t.Fatal("expected first request not to be duplicate")
+	}
+
+	replayed := make(chan *idempotentResponse)
+	go func() {
+		pending, pendingDuplicate := store.Begin("key", time.Minute, now)
+		if !pendingDuplicate {
+			t.Error("expected request in progress to be duplicate")
+		}
+		<-pending.done
+		replayed <- pending
+	}()
+
+	ctx := &fasthttp.RequestCtx{}
+	_, _ = ctx.WriteString("https://t.me/invoice/link")
+	store.SetOrder(resp, "000001")
+	store.Finish("key", resp, ctx)
+
+	if got := string((<-replayed).body); got != "https://t.me/invoice/link" {
+		t.Errorf("expected invoice link to be replayed, got %q", got)
+	}
+
+	if _, duplicate = store.Begin("key", time.Minute, now.Add(2*time.Minute)); duplicate {
+		t.Error("expected expired response not to be replayed")
+	}
+
+	resp, _ = store.Begin("failed", time.Minute, now)
+	ctx = &fasthttp.RequestCtx{}
+	ctx.SetStatusCode(fasthttp.StatusInternalServerError)
+	store.Finish("failed", resp, ctx)
+	if _, duplicate = store.Begin("failed", time.Minute, now); duplicate {
+		t.Error("expected failed request to be retried")
+	}
+
+	resp, _ = store.Begin("1:order:paid", time.Minute, now)
+	store.SetOrder(resp, "000002")
+	store.Finish("1:order:paid", resp, &fasthttp.RequestCtx{})
+	clientResp, _ := store.Begin("1:key:paid:order:1", time.Minute, now)
+	store.SetOrder(clientResp, "000002")
+	store.Finish("1:key:paid:order:1", clientResp, &fasthttp.RequestCtx{})
+
+	store.Forget("000002")
+	if _, duplicate = store.Begin("1:order:paid", time.Minute, now); duplicate {
+		t.Error("expected response of paid order to be forgotten")
+	}
+	replayedResp, duplicate := store.Begin("1:key:paid:order:1", time.Minute, now)
+	if !duplicate || replayedResp != clientResp {
+		t.Error("expected response stored under client key to be kept")
+	}
+}
+
+func TestIdempotencyKey(t *testing.T) {
+	h := &Handler{}
+	h.cfg.Store(&config.Config{Settings: config.Settings{
+		OrderTTL:          30 * time.Minute,
+		IdempotencyWindow: time.Minute,
+	}})
+	order := OrderRequest{AppData: "first", Products: []OrderProduct{{ID: "1", Amount: 2}}}
+
+	key, ttl, err := h.idempotencyKey(&fasthttp.RequestCtx{}, 1, order)
+	if err != nil || ttl != time.Minute {
+		t.Fatalf("unexpected derived key result: %s, %s", ttl, err)
+	}
+
+	order.AppData = "second"
+	if sameKey, _, _ := h.idempotencyKey(&fasthttp.RequestCtx{}, 1, order); sameKey != key {
+		t.Error("expected derived key not to depend on app data")
+	}
+	if otherUserKey, _, _ := h.idempotencyKey(&fasthttp.RequestCtx{}, 2, order); otherUserKey == key {
+		t.Error("expected derived key to depend on user")
+	}
+
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.Header.Set(idempotencyKeyHeader, "client-key")
+	if key, ttl, _ = h.idempotencyKey(ctx, 1, order); key != "1:key:client-key" || ttl != 30*time.Minute {
+		t.Errorf("unexpected client key result: %q, %s", key, ttl)
+	}
+}
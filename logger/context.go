@@ -0,0 +1,51 @@
+package logger
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// correlationIDKey represents context key of correlation ID
+type correlationIDKey struct{}
+
+// CorrelationIDKey represents context key of correlation ID, can be used to set correlation ID in contexts that store
+// values by themselves (e.g. fasthttp.RequestCtx)
+var CorrelationIDKey any = correlationIDKey{}
+
+const (
+	correlationIDBytes = 8
+	correlationIDField = "correlationID"
+)
+
+// NewCorrelationID generates new random correlation ID
+func NewCorrelationID() string {
+	id := make([]byte, correlationIDBytes)
+	if _, err := rand.Read(id); err != nil {
+		return "unknown"
+	}
+
+	return hex.EncodeToString(id)
+}
+
+// WithCorrelationID returns copy of context with specified correlation ID
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, CorrelationIDKey, id)
+}
+
+// CorrelationID returns correlation ID from context, empty string is returned if context has no correlation ID
+func CorrelationID(ctx context.Context) string {
+	id, _ := ctx.Value(CorrelationIDKey).(string)
+	return id
+}
+
+// WithContext returns logger that adds correlation ID from context to each log line, the same logger is returned if
+// context has no correlation ID
+func WithContext(log Logger, ctx context.Context) Logger {
+	id := CorrelationID(ctx)
+	if id == "" {
+		return log
+	}
+
+	return WithFields(log, Fields{correlationIDField: id})
+}
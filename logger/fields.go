@@ -0,0 +1,73 @@
+package logger
+
+import "github.com/kataras/golog"
+
+// Fields represents structured data added to log lines
+type Fields = golog.Fields
+
+// WithFields returns logger that adds specified fields to each log line, fields of previous WithFields calls are kept
+func WithFields(log Logger, fields Fields) Logger {
+	merged := make(Fields, len(fields))
+
+	if fl, ok := log.(*fieldsLog); ok {
+		log = fl.log
+		for key, value := range fl.fields {
+			merged[key] = value
+		}
+	}
+
+	for key, value := range fields {
+		merged[key] = value
+	}
+
+	return &fieldsLog{
+		log:    log,
+		fields: merged,
+	}
+}
+
+// fieldsLog represents logger that adds fields to each log line
+type fieldsLog struct {
+	log    Logger
+	fields Fields
+}
+
+func (l *fieldsLog) Fatal(v ...any) {
+	l.log.Fatal(append(v, l.fields)...)
+}
+
+func (l *fieldsLog) Fatalf(format string, args ...any) {
+	l.log.Fatalf(format, append(args, l.fields)...)
+}
+
+func (l *fieldsLog) Error(v ...any) {
+	l.log.Error(append(v, l.fields)...)
+}
+
+func (l *fieldsLog) Errorf(format string, args ...any) {
+	l.log.Errorf(format, append(args, l.fields)...)
+}
+
+func (l *fieldsLog) Warn(v ...any) {
+	l.log.Warn(append(v, l.fields)...)
+}
+
+func (l *fieldsLog) Warnf(format string, args ...any) {
+	l.log.Warnf(format, append(args, l.fields)...)
+}
+
+func (l *fieldsLog) Info(v ...any) {
+	l.log.Info(append(v, l.fields)...)
+}
+
+func (l *fieldsLog) Infof(format string, args ...any) {
+	l.log.Infof(format, append(args, l.fields)...)
+}
+
+func (l *fieldsLog) Debug(v ...any) {
+	l.log.Debug(append(v, l.fields)...)
+}
+
+func (l *fieldsLog) Debugf(format string, args ...any) {
+	l.log.Debugf(format, append(args, l.fields)...)
+}
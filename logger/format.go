@@ -0,0 +1,94 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/kataras/golog"
+	"github.com/kataras/pio"
+)
+
+// Log formats
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// textFormatter represents human-readable format of logs, fields are printed after message as key=value pairs
+type textFormatter struct {
+	log *Log
+}
+
+func (f textFormatter) String() string {
+	return FormatText
+}
+
+func (f textFormatter) Options(_ ...any) golog.Formatter {
+	return f
+}
+
+func (f textFormatter) Format(dest io.Writer, log *golog.Log) bool {
+	message, fields := f.log.redact(log.Message, log.Fields)
+
+	if level, ok := golog.Levels[log.Level]; ok && log.Level != golog.DisableLevel {
+		pio.WriteRich(dest, level.Title, level.ColorCode, level.Style...)
+		_, _ = fmt.Fprint(dest, " ")
+	}
+
+	if t := log.FormatTime(); t != "" {
+		_, _ = fmt.Fprint(dest, t, " ")
+	}
+
+	_, _ = fmt.Fprint(dest, message)
+
+	for _, key := range sortedKeys(fields) {
+		_, _ = fmt.Fprintf(dest, " %s=%v", key, fields[key])
+	}
+
+	_, _ = fmt.Fprintln(dest)
+	return true
+}
+
+// jsonFormatter represents format of logs with one JSON object per line, fields are added at the top level
+type jsonFormatter struct {
+	log *Log
+}
+
+func (f jsonFormatter) String() string {
+	return FormatJSON
+}
+
+func (f jsonFormatter) Options(_ ...any) golog.Formatter {
+	return f
+}
+
+func (f jsonFormatter) Format(dest io.Writer, log *golog.Log) bool {
+	message, fields := f.log.redact(log.Message, log.Fields)
+
+	entry := make(map[string]any, len(fields)+3)
+	for key, value := range fields {
+		if err, ok := value.(error); ok {
+			value = err.Error()
+		}
+		entry[key] = value
+	}
+
+	entry["time"] = log.Time.Format(time.RFC3339Nano)
+	entry["level"] = log.Level.String()
+	entry["message"] = message
+
+	return json.NewEncoder(dest).Encode(entry) == nil
+}
+
+func sortedKeys(fields golog.Fields) []string {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
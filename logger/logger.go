@@ -2,8 +2,7 @@ package logger
 
 import (
 	"fmt"
-	"os"
-	"path/filepath"
+	"sync/atomic"
 
 	"github.com/kataras/golog"
 )
@@ -34,7 +33,8 @@ type Logger interface {
 
 // Log represents Log implementation using golog.Logger
 type Log struct {
-	outputFile *os.File
+	outputFile *rotatingFile
+	redactor   atomic.Pointer[Redactor]
 
 	*golog.Logger
 }
@@ -45,16 +45,49 @@ const logTimeFormat = "02.01.2006 15:04:05 MST"
 func NewLog(log *golog.Logger) *Log {
 	log.SetTimeFormat(logTimeFormat)
 
-	return &Log{
+	l := &Log{
 		Logger: log,
 	}
+	l.redactor.Store(NewRedactor())
+
+	log.RegisterFormatter(textFormatter{log: l})
+	log.RegisterFormatter(jsonFormatter{log: l})
+	log.SetFormat(FormatText)
+
+	return l
+}
+
+// SetRedactor sets redactor used to mask personal data and secrets, nil disables redaction, by default redactor
+// without secrets is used
+func (l *Log) SetRedactor(redactor *Redactor) {
+	l.redactor.Store(redactor)
+}
+
+func (l *Log) redact(message string, fields Fields) (string, Fields) {
+	redactor := l.redactor.Load()
+	if redactor == nil {
+		return message, fields
+	}
+
+	return redactor.Redact(message), redactor.RedactFields(fields)
+}
+
+// SetFormat sets format of logs: text or json
+func (l *Log) SetFormat(format string) error {
+	switch format {
+	case FormatText, FormatJSON:
+		l.Logger.SetFormat(format)
+		return nil
+	default:
+		return fmt.Errorf("unknown log format: %q", format)
+	}
 }
 
 const logFilePerm = 0o600
 
-// SetOutputFile sets output file for logger
-func (l *Log) SetOutputFile(filename string) error {
-	file, err := os.OpenFile(filepath.Clean(filename), os.O_CREATE|os.O_WRONLY|os.O_APPEND, logFilePerm)
+// SetOutputFile sets output file for logger with specified rotation
+func (l *Log) SetOutputFile(filename string, rotation Rotation) error {
+	file, err := newRotatingFile(filename, rotation)
 	if err != nil {
 		return fmt.Errorf("failed to create log file: %w", err)
 	}
@@ -65,6 +98,15 @@ func (l *Log) SetOutputFile(filename string) error {
 	return nil
 }
 
+// Reopen reopens output file if it's used, should be called after log file was moved by external tool
+func (l *Log) Reopen() error {
+	if l.outputFile != nil {
+		return l.outputFile.Reopen()
+	}
+
+	return nil
+}
+
 // Close closes logger if needed
 func (l *Log) Close() error {
 	if l.outputFile != nil {
@@ -0,0 +1,259 @@
+package logger
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const redactedValue = "***"
+
+// redactedFields represents names of fields (in lower case) which values are always redacted, used for structured
+// fields, JSON and URL encoded data and Go structs formatted with %+v
+var redactedFields = []string{
+	"name", "firstname", "first_name", "lastname", "last_name", "username",
+	"phone", "address", "entrance", "apt", "apartment", "floor", "ecode", "comment", "comments",
+	"location", "point", "lat", "lng", "user", "hash", "query_id",
+	"appdata", "token", "bottoken", "providertoken", "signature", "data", "apikey", "x-api-key",
+	"public_key", "sender_first_name", "sender_address", "privatekey", "liqpayprivetkeyenv", "googlemapsapikey",
+	"syodoapikey", "admintoken", "authorization",
+}
+
+var (
+	// phonePattern matches Ukrainian phone numbers
+	phonePattern = regexp.MustCompile(`\+?380\d{9}\b`)
+
+	// botTokenPattern matches Telegram bot tokens
+	botTokenPattern = regexp.MustCompile(`\d{6,12}:[A-Za-z0-9_-]{30,}`)
+
+	// jsonFieldPattern matches start of JSON field, value of any type follows it
+	jsonFieldPattern = regexp.MustCompile(`"([A-Za-z_-]+)"\s*:\s*`)
+
+	// urlFieldPattern matches values of URL encoded fields
+	urlFieldPattern = regexp.MustCompile(`\b([A-Za-z_-]+)=[^&\s"]*`)
+
+	// structFieldPattern matches start of field of Go struct formatted with %+v
+	structFieldPattern = regexp.MustCompile(`([{ ])([A-Z][A-Za-z0-9]*):`)
+)
+
+// Redactor represents a way to mask personal data and secrets in logs
+type Redactor struct {
+	fields  map[string]struct{}
+	secrets []string
+}
+
+// NewRedactor creates new Redactor, all occurrences of specified secrets will be masked in addition to known fields
+// and patterns
+func NewRedactor(secrets ...string) *Redactor {
+	r := &Redactor{
+		fields: make(map[string]struct{}, len(redactedFields)),
+	}
+
+	for _, field := range redactedFields {
+		r.fields[field] = struct{}{}
+	}
+
+	for _, secret := range secrets {
+		if secret != "" {
+			r.secrets = append(r.secrets, secret)
+		}
+	}
+
+	return r
+}
+
+// Redact masks personal data and secrets in text
+func (r *Redactor) Redact(text string) string {
+	for _, secret := range r.secrets {
+		text = strings.ReplaceAll(text, secret, redactedValue)
+	}
+
+	text = botTokenPattern.ReplaceAllString(text, redactedValue)
+
+	text = r.redactJSONFields(text)
+
+	text = urlFieldPattern.ReplaceAllStringFunc(text, func(match string) string {
+		field := urlFieldPattern.FindStringSubmatch(match)[1]
+		if !r.isRedacted(field) {
+			return match
+		}
+		return field + "=" + redactedValue
+	})
+
+	text = r.redactStructFields(text)
+
+	return phonePattern.ReplaceAllStringFunc(text, MaskPhone)
+}
+
+// RedactFields returns copy of fields with masked values
+func (r *Redactor) RedactFields(fields Fields) Fields {
+	if len(fields) == 0 {
+		return fields
+	}
+
+	redacted := make(Fields, len(fields))
+	for key, value := range fields {
+		switch {
+		case r.isRedacted(key):
+			redacted[key] = redactedValue
+		default:
+			if text, ok := value.(string); ok {
+				redacted[key] = r.Redact(text)
+			} else {
+				redacted[key] = value
+			}
+		}
+	}
+
+	return redacted
+}
+
+func (r *Redactor) isRedacted(field string) bool {
+	_, ok := r.fields[strings.ToLower(field)]
+	return ok
+}
+
+// redactJSONFields masks values of redacted JSON fields, values of any type (strings, numbers, objects, arrays and
+// literals) are replaced with redacted string
+func (r *Redactor) redactJSONFields(text string) string {
+	matches := jsonFieldPattern.FindAllStringSubmatchIndex(text, -1)
+	if len(matches) == 0 {
+		return text
+	}
+
+	var result strings.Builder
+	last := 0
+
+	for _, match := range matches {
+		field := text[match[2]:match[3]]
+		if match[0] < last || !r.isRedacted(field) {
+			continue
+		}
+
+		result.WriteString(text[last:match[0]])
+		result.WriteString(fmt.Sprintf("%q:%q", field, redactedValue))
+		last = match[1] + jsonValueEnd(text[match[1]:])
+	}
+
+	result.WriteString(text[last:])
+	return result.String()
+}
+
+// jsonValueEnd returns index after JSON value that text starts with, nested objects and arrays are skipped whole, or
+// length of text if value is not closed
+func jsonValueEnd(text string) int {
+	if text == "" {
+		return 0
+	}
+
+	if text[0] != '{' && text[0] != '[' && text[0] != '"' {
+		if end := strings.IndexAny(text, ",}] \t\r\n"); end >= 0 {
+			return end
+		}
+		return len(text)
+	}
+
+	depth := 0
+	inString := false
+	escaped := false
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case escaped:
+			escaped = false
+		case inString && c == '\\':
+			escaped = true
+		case c == '"':
+			inString = !inString
+			if !inString && depth == 0 {
+				return i + 1
+			}
+		case inString:
+		case c == '{' || c == '[':
+			depth++
+		case c == '}' || c == ']':
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+
+	return len(text)
+}
+
+// redactStructFields masks values of redacted fields in Go structs formatted with %+v, value ends before the next
+// field or at the end of the struct, so values with spaces are masked as well, nested struct values are masked whole
+func (r *Redactor) redactStructFields(text string) string {
+	matches := structFieldPattern.FindAllStringSubmatchIndex(text, -1)
+	if len(matches) == 0 {
+		return text
+	}
+
+	var result strings.Builder
+	last := 0
+
+	for i, match := range matches {
+		field := text[match[4]:match[5]]
+		if match[0] < last || !r.isRedacted(field) {
+			continue
+		}
+
+		valueStart := match[1]
+		valueEnd := len(text)
+		if strings.HasPrefix(text[valueStart:], "{") {
+			valueEnd = valueStart + structEnd(text[valueStart:])
+		} else {
+			if i+1 < len(matches) {
+				valueEnd = matches[i+1][0]
+			}
+			if end := strings.IndexAny(text[valueStart:valueEnd], "{}"); end >= 0 {
+				valueEnd = valueStart + end
+			}
+		}
+
+		result.WriteString(text[last:valueStart])
+		result.WriteString(redactedValue)
+		last = valueEnd
+	}
+
+	result.WriteString(text[last:])
+	return result.String()
+}
+
+// structEnd returns index after closing brace of struct that text starts with, or length of text if struct is not
+// closed
+func structEnd(text string) int {
+	depth := 0
+	for i, c := range text {
+		switch c {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+
+	return len(text)
+}
+
+const (
+	phoneVisiblePrefix = 6
+	phoneVisibleSuffix = 2
+)
+
+// MaskPhone hides middle digits of phone number, e.g. +380671234567 to +38067*****67, short values are hidden
+// completely
+func MaskPhone(phone string) string {
+	runes := []rune(phone)
+	if len(runes) <= phoneVisiblePrefix+phoneVisibleSuffix {
+		return strings.Repeat("*", len(runes))
+	}
+
+	return string(runes[:phoneVisiblePrefix]) +
+		strings.Repeat("*", len(runes)-phoneVisiblePrefix-phoneVisibleSuffix) +
+		string(runes[len(runes)-phoneVisibleSuffix:])
+}
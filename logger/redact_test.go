@@ -0,0 +1,79 @@
+package logger
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRedactor(t *testing.T) {
+	r := NewRedactor("secret-api-key")
+
+	tests := []struct {
+		text     string
+		expected string
+	}{
+		{
+			text:     `Bad order info: {Name:Taras Shevchenko Phone:+380671234567 City:Lviv}`,
+			expected: `Bad order info: {Name:*** Phone:*** City:Lviv}`,
+		},
+		{
+			text:     `Request data: {"name":"Taras","phone":"+380671234567","city":"Lviv"}`,
+			expected: `Request data: {"name":"***","phone":"***","city":"Lviv"}`,
+		},
+		{
+			text:     `Order: {Floor:3 Apartment:12 Location:{Lat:50.45 Lng:30.52} Promotion:}`,
+			expected: `Order: {Floor:*** Apartment:*** Location:*** Promotion:}`,
+		},
+		{
+			text:     `Invalid web app data: "query_id=AAF&user=%7B%22id%22%3A1%7D&auth_date=1679140800&hash=abc"`,
+			expected: `Invalid web app data: "query_id=***&user=***&auth_date=1679140800&hash=***"`,
+		},
+		{
+			text:     `Payments callback data: signature=abc&data=eyJhIjoxfQ==`,
+			expected: `Payments callback data: signature=***&data=***`,
+		},
+		{
+			text:     `Webhook: /bot/123456789:AAHdqTcvCH1vGWJxfSeofSAs0K5PALDsaw0`,
+			expected: `Webhook: /bot/***`,
+		},
+		{
+			text:     `Call with key secret-api-key failed`,
+			expected: `Call with key *** failed`,
+		},
+		{
+			text: `Request to "/price": data: {"order":[{"id":"1","amount":2}],"deliveryDetails":{"type":"delivery",` +
+				`"point":{"lat":49.84,"lng":24.03}},"selectedPromotion":""}`,
+			expected: `Request to "/price": data: {"order":[{"id":"1","amount":2}],"deliveryDetails":{"type":"delivery",` +
+				`"point":"***"},"selectedPromotion":""}`,
+		},
+		{
+			text:     `Location: {"lat": -49.84e1, "lng": 24, "location": [49.84, 24.03], "note": "lat"}`,
+			expected: `Location: {"lat":"***", "lng":"***", "location":"***", "note": "lat"}`,
+		},
+		{
+			text:     `Call from +380671234567`,
+			expected: `Call from +38067*****67`,
+		},
+	}
+
+	for _, tt := range tests {
+		if actual := r.Redact(tt.text); actual != tt.expected {
+			t.Errorf("redact %q:\nexpected: %q\nactual:   %q", tt.text, tt.expected, actual)
+		}
+	}
+
+	fields := r.RedactFields(Fields{"phone": "+380671234567", "orderID": "000001", "path": "/bot/123456789:" +
+		strings.Repeat("a", 35)})
+	if fields["phone"] != redactedValue || fields["orderID"] != "000001" || fields["path"] != "/bot/"+redactedValue {
+		t.Errorf("unexpected redacted fields: %v", fields)
+	}
+}
+
+func TestMaskPhone(t *testing.T) {
+	phones := map[string]string{"+380671234567": "+38067*****67", "12345": "*****", "": ""}
+	for phone, expected := range phones {
+		if actual := MaskPhone(phone); actual != expected {
+			t.Errorf("mask %q: expected %q, got %q", phone, expected, actual)
+		}
+	}
+}
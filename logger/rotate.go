@@ -0,0 +1,300 @@
+package logger
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	backupTimeFormat = "20060102T150405.000"
+	compressSuffix   = ".gz"
+)
+
+// Rotation represents settings of log file rotation, zero values disable corresponding rotation or retention rule
+type Rotation struct {
+	// MaxSize is a maximal size of log file in bytes before it's rotated
+	MaxSize int64
+	// Interval is a maximal time between rotations
+	Interval time.Duration
+	// MaxFiles is a maximal number of rotated files to keep
+	MaxFiles int
+	// MaxAge is a maximal age of rotated files to keep
+	MaxAge time.Duration
+	// Compress enables gzip compression of rotated files
+	Compress bool
+}
+
+// rotatingFile represents log file that is rotated by size and time
+type rotatingFile struct {
+	filename string
+	rotation Rotation
+
+	file      *os.File
+	size      int64
+	rotatedAt time.Time
+	lock      sync.Mutex
+
+	// background represents running compression and cleanup of rotated files, backgroundLock ensures that only one of
+	// them is running at a time
+	background     sync.WaitGroup
+	backgroundLock sync.Mutex
+}
+
+// newRotatingFile opens log file with specified rotation
+func newRotatingFile(filename string, rotation Rotation) (*rotatingFile, error) {
+	f := &rotatingFile{
+		filename: filepath.Clean(filename),
+		rotation: rotation,
+	}
+
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+func (f *rotatingFile) open() error {
+	file, size, err := f.openFile()
+	if err != nil {
+		return err
+	}
+
+	f.file = file
+	f.size = size
+	f.rotatedAt = time.Now()
+
+	return nil
+}
+
+// openFile opens log file for appending and returns it with its current size
+func (f *rotatingFile) openFile() (*os.File, int64, error) {
+	file, err := os.OpenFile(f.filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, logFilePerm)
+	if err != nil {
+		return nil, 0, fmt.Errorf("open log file: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return nil, 0, fmt.Errorf("stat log file: %w", err)
+	}
+
+	return file, info.Size(), nil
+}
+
+// replace switches to new log file and closes previous one
+func (f *rotatingFile) replace(file *os.File, size int64) error {
+	previous := f.file
+
+	f.file = file
+	f.size = size
+	f.rotatedAt = time.Now()
+
+	if err := previous.Close(); err != nil {
+		return fmt.Errorf("close log file: %w", err)
+	}
+
+	return nil
+}
+
+// Write writes data to log file, rotating it if needed
+func (f *rotatingFile) Write(data []byte) (int, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.needsRotation(int64(len(data))) {
+		// Failed rotation keeps current file, so logs are still written
+		if err := f.rotate(); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Rotate log file %q: %s\n", f.filename, err)
+		}
+	}
+
+	n, err := f.file.Write(data)
+	f.size += int64(n)
+
+	return n, err
+}
+
+func (f *rotatingFile) needsRotation(size int64) bool {
+	if f.size == 0 {
+		return false
+	}
+
+	if f.rotation.MaxSize > 0 && f.size+size > f.rotation.MaxSize {
+		return true
+	}
+
+	return f.rotation.Interval > 0 && time.Since(f.rotatedAt) >= f.rotation.Interval
+}
+
+// rotate renames current log file and opens new one, compression and cleanup of old files are done in background,
+// current file is kept open until new one is opened, so on failure logs are still written to it
+func (f *rotatingFile) rotate() error {
+	ext := filepath.Ext(f.filename)
+	backup := strings.TrimSuffix(f.filename, ext) + "-" + time.Now().Format(backupTimeFormat) + ext
+	if err := os.Rename(f.filename, backup); err != nil {
+		return fmt.Errorf("rename log file: %w", err)
+	}
+
+	file, size, err := f.openFile()
+	if err != nil {
+		if renameErr := os.Rename(backup, f.filename); renameErr != nil {
+			return fmt.Errorf("%w, rename back: %s", err, renameErr)
+		}
+		return err
+	}
+
+	if err = f.replace(file, size); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Rotate log file %q: %s\n", f.filename, err)
+	}
+
+	f.background.Add(1)
+	go func() {
+		defer f.background.Done()
+
+		f.backgroundLock.Lock()
+		defer f.backgroundLock.Unlock()
+
+		if f.rotation.Compress {
+			if err := compressFile(backup); err != nil {
+				_, _ = fmt.Fprintf(os.Stderr, "Compress log file %q: %s\n", backup, err)
+			}
+		}
+
+		if err := f.cleanup(); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Cleanup log files: %s\n", err)
+		}
+	}()
+
+	return nil
+}
+
+// Reopen opens log file again and closes previous one, used when log file was moved by external tool (e.g.
+// logrotate), on failure previous file is kept
+func (f *rotatingFile) Reopen() error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	file, size, err := f.openFile()
+	if err != nil {
+		return err
+	}
+
+	return f.replace(file, size)
+}
+
+// Close closes log file and waits for background work to finish
+func (f *rotatingFile) Close() error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.background.Wait()
+	return f.file.Close()
+}
+
+// cleanup removes rotated files that exceed max files or max age
+func (f *rotatingFile) cleanup() error {
+	if f.rotation.MaxFiles <= 0 && f.rotation.MaxAge <= 0 {
+		return nil
+	}
+
+	backups, err := f.backups()
+	if err != nil {
+		return err
+	}
+
+	for i, backup := range backups {
+		if (f.rotation.MaxFiles > 0 && i >= f.rotation.MaxFiles) ||
+			(f.rotation.MaxAge > 0 && time.Since(backup.modTime) > f.rotation.MaxAge) {
+			if err = os.Remove(backup.path); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("remove log file: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+type backupFile struct {
+	path    string
+	modTime time.Time
+}
+
+// backups returns rotated files sorted from the newest to the oldest
+func (f *rotatingFile) backups() ([]backupFile, error) {
+	ext := filepath.Ext(f.filename)
+	prefix := filepath.Base(strings.TrimSuffix(f.filename, ext)) + "-"
+
+	entries, err := os.ReadDir(filepath.Dir(f.filename))
+	if err != nil {
+		return nil, fmt.Errorf("read log dir: %w", err)
+	}
+
+	var backups []backupFile
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+
+		timestamp := strings.TrimSuffix(strings.TrimSuffix(strings.TrimPrefix(name, prefix), compressSuffix), ext)
+		if _, err = time.Parse(backupTimeFormat, timestamp); err != nil {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+
+		backups = append(backups, backupFile{
+			path:    filepath.Join(filepath.Dir(f.filename), name),
+			modTime: info.ModTime(),
+		})
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].modTime.After(backups[j].modTime)
+	})
+
+	return backups, nil
+}
+
+// compressFile compresses file using gzip and removes original one
+func compressFile(filename string) error {
+	source, err := os.Open(filepath.Clean(filename))
+	if err != nil {
+		return fmt.Errorf("open: %w", err)
+	}
+	defer func() { _ = source.Close() }()
+
+	target, err := os.OpenFile(filename+compressSuffix, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, logFilePerm)
+	if err != nil {
+		return fmt.Errorf("create: %w", err)
+	}
+
+	writer := gzip.NewWriter(target)
+	if _, err = io.Copy(writer, source); err != nil {
+		_ = target.Close()
+		return fmt.Errorf("compress: %w", err)
+	}
+
+	if err = writer.Close(); err != nil {
+		_ = target.Close()
+		return fmt.Errorf("compress: %w", err)
+	}
+
+	if err = target.Close(); err != nil {
+		return fmt.Errorf("close: %w", err)
+	}
+
+	return os.Remove(filename)
+}
@@ -0,0 +1,89 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRotatingFile(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "server.log")
+
+	file, err := newRotatingFile(filename, Rotation{
+		MaxSize:  10,
+		MaxFiles: 2,
+		Compress: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 4; i++ {
+		if _, err = file.Write([]byte("log line\n")); err != nil {
+			t.Fatal(err)
+		}
+		// Rotated files are named by time with millisecond precision
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	if err = file.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var current, compressed int
+	for _, entry := range entries {
+		switch {
+		case entry.Name() == "server.log":
+			current++
+		case strings.HasPrefix(entry.Name(), "server-") && strings.HasSuffix(entry.Name(), ".log.gz"):
+			compressed++
+		default:
+			t.Errorf("unexpected file: %s", entry.Name())
+		}
+	}
+
+	if current != 1 || compressed != 2 {
+		t.Errorf("expected 1 current and 2 compressed files, got: %d, %d", current, compressed)
+	}
+}
+
+func TestRotatingFileFailure(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "logs")
+	if err := os.Mkdir(dir, 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := newRotatingFile(filepath.Join(dir, "server.log"), Rotation{MaxSize: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = file.Write([]byte("log line\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	// Log file can't be renamed or opened again, so current one should be kept
+	if err = os.RemoveAll(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = file.Reopen(); err == nil {
+		t.Error("expected reopen error")
+	}
+
+	if _, err = file.Write([]byte("log line\n")); err != nil {
+		t.Errorf("expected write after failed rotation, got: %s", err)
+	}
+
+	if err = file.Close(); err != nil {
+		t.Errorf("expected current file to be open, got: %s", err)
+	}
+}
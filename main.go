@@ -23,11 +23,18 @@ import (
 )
 
 var (
-	configFile = flag.String("config", "config.toml", "Config file")
-	textFile   = flag.String("text", "text.toml", "Text data file")
+	configFile     = flag.String("config", "config.toml", "Config file")
+	textFile       = flag.String("text", "text.toml", "Text data file")
+	catalogFile    = flag.String("catalog", "catalog.toml", "Catalog file")
+	promotionsFile = flag.String("promotions", "promotions.toml", "Promotions file")
+	promoCodesFile = flag.String("promo-codes", "promocodes.toml", "Promo codes file")
+	promoUsageFile = flag.String("promo-code-usages", "promocode-usages.json", "Promo code usages file")
 
 	versionRequest   = flag.Bool("version", false, "Version")
 	buildInfoRequest = flag.Bool("build-info", false, "Build info")
+
+	checkTextRequest   = flag.Bool("check-text", false, "Check text data file and exit")
+	printConfigRequest = flag.Bool("print-config", false, "Print effective config with secrets masked and exit")
 )
 
 func main() {
@@ -45,11 +52,26 @@ func main() {
 	}
 	// ==== Build Info End ====
 
+	// ==== Check Text ====
+	if *checkTextRequest {
+		if !checkText(*textFile, *catalogFile) {
+			os.Exit(1)
+		}
+		return
+	}
+	// ==== Check Text End ====
+
 	fmt.Println("Starting...")
 
 	// ==== Config ====
 	cfg, err := config.LoadConfig(*configFile)
 	assert(err == nil, fmt.Errorf("load config: %w", err))
+
+	if *printConfigRequest {
+		err = cfg.Print(os.Stdout)
+		assert(err == nil, fmt.Errorf("print config: %w", err))
+		return
+	}
 	// ==== Config End ====
 
 	// ==== Logger ====
@@ -71,12 +93,30 @@ func start(cfg *config.Config, log *logger.Log) {
 
 	rand.Seed(time.Now().Unix())
 
-	textData, err := LoadTextData(*textFile)
+	metrics := NewMetrics()
+	syodo := NewSyodoService(cfg, log, metrics)
+
+	catalog, err := LoadCatalog(*catalogFile)
+	if err != nil {
+		log.Fatalf("Read catalog file: %s", err)
+	}
+
+	textData, err := LoadTextData(*textFile, syodo.timezone, catalog)
 	if err != nil {
 		log.Fatalf("Read text data file: %s", err)
 	}
 
-	delivery, err := NewDeliveryStrategy(cfg, log)
+	promotions, err := LoadPromotions(*promotionsFile)
+	if err != nil {
+		log.Fatalf("Read promotions file: %s", err)
+	}
+
+	promoCodes, err := LoadPromoCodes(*promoCodesFile, *promoUsageFile)
+	if err != nil {
+		log.Fatalf("Read promo codes file: %s", err)
+	}
+
+	delivery, err := NewDeliveryStrategy(cfg, log, metrics)
 	if err != nil {
 		log.Fatalf("Init delivery strategy: %s", err)
 	}
@@ -90,7 +130,7 @@ func start(cfg *config.Config, log *logger.Log) {
 	srv := &fasthttp.Server{}
 	var updates <-chan telego.Update
 
-	if cfg.Settings.UseLongPulling {
+	if cfg.Settings.UseLongPolling {
 		srv.Handler = rtr.Handler
 
 		err = bot.DeleteWebhook(&telego.DeleteWebhookParams{})
@@ -121,16 +161,43 @@ func start(cfg *config.Config, log *logger.Log) {
 		log.Fatalf("Get updates: %s", err)
 	}
 
+	schedule, err := NewWorkingHours(cfg.Schedule, syodo.timezone)
+	if err != nil {
+		log.Fatalf("Init working hours: %s", err)
+	}
+
 	bh, err := th.NewBotHandler(bot, updates, th.WithStopTimeout(cfg.Settings.StopTimeout))
 	if err != nil {
 		log.Fatalf("Create bot handler: %s", err)
 	}
 	// ==== Dependencies Setup End ====
 
-	handler := NewHandler(cfg, log, bot, bh, rtr, textData, delivery)
+	adminRtr := router.New()
+	adminSrv := &fasthttp.Server{Handler: adminRtr.Handler}
+
+	handler := NewHandler(cfg, log, bot, bh, rtr, adminRtr, textData, catalog, promotions, promoCodes, delivery, syodo,
+		schedule, metrics, ReloadFiles{Config: *configFile, Text: *textFile})
 	handler.RegisterHandlers()
 
 	// ==== Starting / Stopping ====
+	reloads := make(chan os.Signal, 1)
+	signal.Notify(reloads, syscall.SIGHUP)
+
+	go func() {
+		for range reloads {
+			if reopenErr := log.Reopen(); reopenErr != nil {
+				log.Errorf("Reopen log file: %s", reopenErr)
+			}
+
+			log.Info("Reloading")
+			if reloadErr := handler.Reload(); reloadErr != nil {
+				log.Errorf("Reload: %s", reloadErr)
+				continue
+			}
+			log.Info("Reloaded")
+		}
+	}()
+
 	sigs := make(chan os.Signal, 1)
 	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
 	done := make(chan struct{}, 1)
@@ -139,7 +206,7 @@ func start(cfg *config.Config, log *logger.Log) {
 		<-sigs
 		log.Info("Stopping")
 
-		if cfg.Settings.UseLongPulling {
+		if cfg.Settings.UseLongPolling {
 			bot.StopLongPolling()
 			err = srv.Shutdown()
 		} else {
@@ -149,6 +216,10 @@ func start(cfg *config.Config, log *logger.Log) {
 			log.Fatalf("Stop server: %s", err)
 		}
 
+		if err = adminSrv.Shutdown(); err != nil {
+			log.Fatalf("Stop admin server: %s", err)
+		}
+
 		bh.Stop()
 
 		done <- struct{}{}
@@ -157,7 +228,14 @@ func start(cfg *config.Config, log *logger.Log) {
 	log.Info("Handling updates")
 	go bh.Start()
 
-	if cfg.Settings.UseLongPulling {
+	go func() {
+		log.Infof("Admin server listening on %s", cfg.Settings.AdminHost)
+		if adminErr := adminSrv.ListenAndServe(cfg.Settings.AdminHost); adminErr != nil {
+			log.Fatalf("Start admin server: %s", adminErr)
+		}
+	}()
+
+	if cfg.Settings.UseLongPolling {
 		err = srv.ListenAndServe(cfg.Settings.ServerHost)
 	} else {
 		go func() {
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/valyala/fasthttp"
+	"github.com/valyala/fasthttp/fasthttpadaptor"
+)
+
+const metricsNamespace = "syodo"
+
+// Order validation failure reasons
+const (
+	failureRateLimited    = "rate_limited"
+	failureBadRequest     = "bad_request"
+	failureAppData        = "app_data"
+	failureAppDataExpired = "app_data_expired"
+	failureOrderInfo      = "order_info"
+	failureScheduledTime  = "scheduled_time"
+	failureChangeFrom     = "change_from"
+	failureClosed         = "closed"
+	failurePromotion      = "promotion"
+	failurePromoCode      = "promo_code"
+	failureDeliveryType   = "delivery_type"
+	failurePrice          = "price"
+	failureInvoicePrices  = "invoice_prices"
+	failureInvoiceCreated = "invoice"
+)
+
+// Metrics represents Prometheus metrics of the bot
+type Metrics struct {
+	registry *prometheus.Registry
+
+	ordersReceived     prometheus.Counter
+	duplicateOrders    prometheus.Counter
+	validationFailures *prometheus.CounterVec
+	geocodeDuration    prometheus.Histogram
+	geocodeErrors      prometheus.Counter
+	syodoDuration      *prometheus.HistogramVec
+	invoicesCreated    prometheus.Counter
+	preCheckouts       *prometheus.CounterVec
+	successPayments    *prometheus.CounterVec
+	revenue            *prometheus.CounterVec
+}
+
+// NewMetrics creates new Metrics and registers them in separate registry along with Go runtime & process metrics
+func NewMetrics() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		ordersReceived: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "orders_received_total",
+			Help:      "Number of received order requests",
+		}),
+		duplicateOrders: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "orders_duplicate_total",
+			Help:      "Number of duplicate order requests answered with response of original request",
+		}),
+		validationFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "order_failures_total",
+			Help:      "Number of rejected order requests by reason",
+		}, []string{"reason"}),
+		geocodeDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "geocode_duration_seconds",
+			Help:      "Latency of Google Maps geocoding",
+			Buckets:   prometheus.DefBuckets,
+		}),
+		geocodeErrors: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "geocode_errors_total",
+			Help:      "Number of failed Google Maps geocoding requests",
+		}),
+		syodoDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "syodo_api_duration_seconds",
+			Help:      "Latency of Syodo API requests by path and status, status is 0 if request failed",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"path", "status"}),
+		invoicesCreated: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "invoices_created_total",
+			Help:      "Number of created invoice links",
+		}),
+		preCheckouts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "pre_checkouts_total",
+			Help:      "Number of answered pre-checkout queries by result",
+		}, []string{"result"}),
+		successPayments: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "success_payments_total",
+			Help:      "Number of successful payments by payment method, cash and card orders are counted when confirmed",
+		}, []string{"method"}),
+		revenue: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "revenue_uah_total",
+			Help:      "Sum of successful payments in UAH by payment method",
+		}, []string{"method"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.ordersReceived,
+		m.duplicateOrders,
+		m.validationFailures,
+		m.geocodeDuration,
+		m.geocodeErrors,
+		m.syodoDuration,
+		m.invoicesCreated,
+		m.preCheckouts,
+		m.successPayments,
+		m.revenue,
+	)
+
+	return m
+}
+
+// RegisterOrderStoreSize registers gauge of number of stored orders
+func (m *Metrics) RegisterOrderStoreSize(size func() int) {
+	m.registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "order_store_size",
+		Help:      "Number of orders waiting for payment",
+	}, func() float64 {
+		return float64(size())
+	}))
+}
+
+// Handler returns HTTP handler that exposes metrics
+func (m *Metrics) Handler() fasthttp.RequestHandler {
+	return fasthttpadaptor.NewFastHTTPHandler(promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{}))
+}
+
+func (m *Metrics) orderFailed(reason string) {
+	m.validationFailures.WithLabelValues(reason).Inc()
+}
+
+func (m *Metrics) preCheckout(ok bool) {
+	result := "rejected"
+	if ok {
+		result = "approved"
+	}
+	m.preCheckouts.WithLabelValues(result).Inc()
+}
+
+// orderPaid records successful payment of order, amount is in UAH
+func (m *Metrics) orderPaid(paymentMethod string, amount float64) {
+	if paymentMethod == "" {
+		paymentMethod = paymentMethodOnline
+	}
+	m.successPayments.WithLabelValues(paymentMethod).Inc()
+	m.revenue.WithLabelValues(paymentMethod).Add(amount)
+}
+
+func (m *Metrics) syodoCall(path string, statusCode int, seconds float64) {
+	m.syodoDuration.WithLabelValues(path, strconv.Itoa(statusCode)).Observe(seconds)
+}
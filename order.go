@@ -1,11 +1,18 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"math/rand"
+	"net/url"
+	"strconv"
 	"time"
 
 	"github.com/mymmrac/memkey"
+	"github.com/mymmrac/telego"
+	tu "github.com/mymmrac/telego/telegoutil"
 	"googlemaps.github.io/maps"
 )
 
@@ -32,6 +39,11 @@ type OrderRequest struct {
 	DeliveryType         string         `json:"deliveryType"`
 	Location             maps.LatLng    `json:"-"`
 	Promotion            string         `json:"promotion"`
+	PromoCode            string         `json:"promoCode"`
+	DeliveryDate         string         `json:"deliveryDate"`
+	DeliveryTime         string         `json:"deliveryTime"`
+	PaymentMethod        string         `json:"paymentMethod"`
+	ChangeFrom           int            `json:"changeFrom"` // Banknote in UAH to give change from, only for cash
 	City                 string         `json:"city"`
 	Address              string         `json:"address"`
 	Entrance             string         `json:"entrance"`
@@ -47,23 +59,60 @@ type OrderDetails struct {
 	Request         OrderRequest `json:"request"`
 	OrderURL        string       `json:"orderURL"`
 	ServiceArea     string       `json:"serviceArea"`
+	ScheduledAt     time.Time    `json:"scheduledAt"`
 	TotalAmount     float64      `json:"totalAmount"`
-	CreatedAt       time.Time    `json:"createdAt"`
+	// PromoCodeDiscount represents discount of promo code in kopecks, calculated when order is created
+	PromoCodeDiscount int       `json:"promoCodeDiscount"`
+	CreatedAt         time.Time `json:"createdAt"`
+	CorrelationID     string    `json:"correlationID"`
+	UserID            int64     `json:"userID"`
+	ChatID            int64     `json:"chatID"`
 }
 
-func (h *Handler) storeOrder(order OrderRequest, area string) string {
+// webAppUser returns user that opened web app from validated web app data
+func webAppUser(appData url.Values) (telego.User, error) {
+	var user telego.User
+	if err := json.Unmarshal([]byte(appData.Get(tu.WebAppUser)), &user); err != nil {
+		return telego.User{}, fmt.Errorf("decode user: %w", err)
+	}
+
+	if user.ID == 0 {
+		return telego.User{}, errors.New("no user ID")
+	}
+
+	return user, nil
+}
+
+// webAppAuthDate returns time when web app data was created from validated web app data
+func webAppAuthDate(appData url.Values) (time.Time, error) {
+	authDate, err := strconv.ParseInt(appData.Get(tu.WebAppAuthDate), 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("parse auth date: %w", err)
+	}
+
+	return time.Unix(authDate, 0), nil
+}
+
+// webAppDataExpired reports if web app data created at auth date is older than max age
+func webAppDataExpired(authDate, now time.Time, maxAge time.Duration) bool {
+	return now.Sub(authDate) > maxAge
+}
+
+// isOrderOwner reports if user is the one who created the order
+func isOrderOwner(order OrderDetails, user *telego.User) bool {
+	return user != nil && user.ID == order.UserID
+}
+
+func (h *Handler) storeOrder(order OrderDetails) string {
 	var orderKey string
 	for orderKey == "" || h.orderStore.Has(orderKey) {
 		//nolint:gosec
 		orderKey = fmt.Sprintf("%06d", rand.Intn(orderKeyBound))
 	}
 
-	memkey.Set(h.orderStore, orderKey, OrderDetails{
-		OrderID:     orderKey,
-		Request:     order,
-		ServiceArea: area,
-		CreatedAt:   time.Now().UTC(),
-	})
+	order.OrderID = orderKey
+	order.CreatedAt = time.Now().UTC()
+	memkey.Set(h.orderStore, orderKey, order)
 
 	return orderKey
 }
@@ -76,12 +125,40 @@ func (h *Handler) updateOrder(order OrderDetails) {
 	memkey.Set(h.orderStore, order.OrderID, order)
 }
 
+// orderTotal returns amount to pay for order in kopecks
+func orderTotal(products []OrderProduct, price PriceResponse) int {
+	return productsSum(products) + price.Delivery - price.Discount
+}
+
+// coversTotal reports if banknote to give change from (in UAH) is enough to pay order total (in kopecks), zero
+// means that change is not needed
+func coversTotal(changeFrom, total int) bool {
+	return changeFrom == 0 || changeFrom*priceMultiplier >= total
+}
+
+// discardOrder removes order that will not be completed and releases promo code reserved for it
+func (h *Handler) discardOrder(key string) {
+	h.orderStore.Delete(key)
+	h.promoCodes.Release(key)
+}
+
+// completeOrder removes completed order and records usage of its promo code
+func (h *Handler) completeOrder(ctx context.Context, order OrderDetails) {
+	h.orderStore.Delete(order.OrderID)
+
+	if order.Request.PromoCode != "" {
+		if err := h.promoCodes.Redeem(order.OrderID); err != nil {
+			h.logFor(ctx).Errorf("Redeem promo code %q: %s", order.Request.PromoCode, err)
+		}
+	}
+}
+
 func (h *Handler) invalidateOldOrders() {
-	ttlTime := time.Now().UTC().Add(-h.cfg.Settings.OrderTTL)
+	ttlTime := time.Now().UTC().Add(-h.config().Settings.OrderTTL)
 
 	for _, e := range memkey.Entries[OrderDetails](h.orderStore) {
 		if ttlTime.After(e.Value.CreatedAt) {
-			h.orderStore.Delete(e.Key)
+			h.discardOrder(e.Key)
 		}
 	}
 }
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mymmrac/telego"
+	tu "github.com/mymmrac/telego/telegoutil"
+	"googlemaps.github.io/maps"
+
+	"github.com/mymmrac/syodo-telegram-bot/logger"
+)
+
+func TestOrderRedacted(t *testing.T) {
+	order := OrderDetails{
+		OrderID: "000001",
+		Request: OrderRequest{
+			AppData:   "query_id=AAF&user=%7B%22first_name%22%3A%22Taras%22%7D&auth_date=1679140800&hash=abc",
+			Comment:   "Private comment",
+			Name:      "Taras Shevchenko",
+			Phone:     "+380671234567",
+			Location:  maps.LatLng{Lat: 50.4501, Lng: 30.5234},
+			Address:   "Private street 1",
+			Entrance:  "Private entrance",
+			ECode:     "Private code",
+			Floor:     "Private floor",
+			Apartment: "Private apartment",
+		},
+	}
+
+	encoded, err := json.Marshal(order)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	redactor := logger.NewRedactor()
+	outputs := map[string]string{
+		"struct":   fmt.Sprintf("%+v", order),
+		"request":  fmt.Sprintf("%+v", order.Request),
+		"json":     string(encoded),
+		"app_data": fmt.Sprintf("Invalid web app data: %q", order.Request.AppData),
+	}
+
+	personalData := []string{"Private", "Taras", "Shevchenko", "671234567", "50.4501", "30.5234", "hash=abc"}
+	for name, output := range outputs {
+		redacted := redactor.Redact(output)
+		for _, data := range personalData {
+			if strings.Contains(redacted, data) {
+				t.Errorf("%s: %q not redacted in: %s", name, data, redacted)
+			}
+		}
+	}
+}
+
+func TestWebAppAuthDate(t *testing.T) {
+	authDate, err := webAppAuthDate(url.Values{tu.WebAppAuthDate: {"1679140800"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := time.Date(2023, 3, 18, 12, 0, 0, 0, time.UTC); !authDate.Equal(expected) {
+		t.Errorf("expected auth date %s, got %s", expected, authDate)
+	}
+
+	for _, value := range []string{"", "yesterday"} {
+		if _, err = webAppAuthDate(url.Values{tu.WebAppAuthDate: {value}}); err == nil {
+			t.Errorf("expected error for auth date %q", value)
+		}
+	}
+
+	now := authDate.Add(time.Hour)
+	if webAppDataExpired(authDate, now, time.Hour) {
+		t.Error("expected data of max age not to be expired")
+	}
+	if !webAppDataExpired(authDate, now.Add(time.Second), time.Hour) {
+		t.Error("expected data older than max age to be expired")
+	}
+}
+
+func TestIsOrderOwner(t *testing.T) {
+	order := OrderDetails{UserID: 1, ChatID: 1}
+
+	if !isOrderOwner(order, &telego.User{ID: 1}) {
+		t.Error("expected user who created order to be owner")
+	}
+	if isOrderOwner(order, &telego.User{ID: 2}) {
+		t.Error("expected other user not to be owner")
+	}
+	if isOrderOwner(order, nil) {
+		t.Error("expected unknown user not to be owner")
+	}
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/go-playground/validator/v10"
+)
+
+const percentBase = 100
+
+// PromoCode represents one-off promo code that gives discount on products
+type PromoCode struct {
+	Code            string `validate:"required"`
+	Label           string `validate:"required"`
+	Labels          Labels `validate:"dive,keys,required,endkeys,required"`
+	DiscountPercent int    `validate:"required_without=DiscountAmount,gte=0,lte=100"`
+	DiscountAmount  int    `validate:"required_without=DiscountPercent,gte=0"`
+	UsageLimit      int    `validate:"gte=0"`
+	ExpiresAt       string `validate:"omitempty,datetime=2006-01-02"`
+}
+
+// LocalizedLabel returns label of promo code for locale
+func (c PromoCode) LocalizedLabel(locale string) string {
+	return c.Labels.localize(locale, c.Label)
+}
+
+// Discount returns discount amount for given sum of products
+func (c PromoCode) Discount(sum int) int {
+	discount := c.DiscountAmount + sum*c.DiscountPercent/percentBase
+	if discount > sum {
+		return sum
+	}
+	return discount
+}
+
+// PromoCodes represents registry of promo codes and their usages, code is reserved for order when order is created and
+// counted as used after it, usages are stored in file, reservations only in memory (as orders)
+type PromoCodes struct {
+	codes        map[string]PromoCode
+	usages       map[string]map[int64]struct{}
+	reservations map[string]promoCodeReservation
+	usagesFile   string
+	lock         sync.Mutex
+}
+
+// promoCodeReservation represents usage of promo code by user in not yet completed order
+type promoCodeReservation struct {
+	code   string
+	userID int64
+}
+
+const promoCodeUsagesPerm = 0o600
+
+// LoadPromoCodes loads promo codes from specified file and their usages from usages file, usages file is created on
+// first usage if it doesn't exist
+func LoadPromoCodes(filename, usagesFilename string) (*PromoCodes, error) {
+	var promoCodesFile struct {
+		PromoCode []PromoCode
+	}
+
+	_, err := toml.DecodeFile(filename, &promoCodesFile)
+	if err != nil {
+		return nil, fmt.Errorf("decode promo codes: %w", err)
+	}
+
+	validate := validator.New()
+	promoCodes := &PromoCodes{
+		codes:        make(map[string]PromoCode, len(promoCodesFile.PromoCode)),
+		usages:       make(map[string]map[int64]struct{}),
+		reservations: make(map[string]promoCodeReservation),
+		usagesFile:   usagesFilename,
+	}
+
+	for _, promoCode := range promoCodesFile.PromoCode {
+		if err = validate.Struct(promoCode); err != nil {
+			return nil, fmt.Errorf("promo code %q validation: %w", promoCode.Code, err)
+		}
+
+		code := normalizePromoCode(promoCode.Code)
+		if _, ok := promoCodes.codes[code]; ok {
+			return nil, fmt.Errorf("duplicate promo code %q", promoCode.Code)
+		}
+
+		promoCode.Code = code
+		promoCodes.codes[code] = promoCode
+	}
+
+	if err = promoCodes.loadUsages(); err != nil {
+		return nil, err
+	}
+
+	return promoCodes, nil
+}
+
+func normalizePromoCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// Get returns promo code by its code
+func (p *PromoCodes) Get(code string) (PromoCode, bool) {
+	promoCode, ok := p.codes[normalizePromoCode(code)]
+	return promoCode, ok
+}
+
+// Validate checks if promo code can be used by user at specified time, reserved usages are counted as used
+func (p *PromoCodes) Validate(code string, userID int64, now time.Time) (PromoCode, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return p.validate(normalizePromoCode(code), userID, now)
+}
+
+func (p *PromoCodes) validate(code string, userID int64, now time.Time) (PromoCode, error) {
+	promoCode, ok := p.codes[code]
+	if !ok {
+		return PromoCode{}, fmt.Errorf("unknown promo code %q", code)
+	}
+
+	if promoCode.ExpiresAt != "" && now.Format(promotionDateLayout) > promoCode.ExpiresAt {
+		return PromoCode{}, fmt.Errorf("promo code %q expired at %s", code, promoCode.ExpiresAt)
+	}
+
+	usages := p.usages[code]
+	if _, ok = usages[userID]; ok {
+		return PromoCode{}, fmt.Errorf("promo code %q already used by %d", code, userID)
+	}
+
+	usageCount := len(usages)
+	for _, reservation := range p.reservations {
+		if reservation.code != code {
+			continue
+		}
+		if reservation.userID == userID {
+			return PromoCode{}, fmt.Errorf("promo code %q already reserved by %d", code, userID)
+		}
+		usageCount++
+	}
+
+	if promoCode.UsageLimit != 0 && usageCount >= promoCode.UsageLimit {
+		return PromoCode{}, fmt.Errorf("promo code %q usage limit reached", code)
+	}
+
+	return promoCode, nil
+}
+
+// Reserve validates promo code and reserves its usage by user for order, reservation should be either released or
+// redeemed
+func (p *PromoCodes) Reserve(code string, userID int64, orderID string, now time.Time) (PromoCode, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	code = normalizePromoCode(code)
+	promoCode, err := p.validate(code, userID, now)
+	if err != nil {
+		return PromoCode{}, err
+	}
+
+	p.reservations[orderID] = promoCodeReservation{
+		code:   code,
+		userID: userID,
+	}
+
+	return promoCode, nil
+}
+
+// Release removes reservation of promo code for order if there is one
+func (p *PromoCodes) Release(orderID string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	delete(p.reservations, orderID)
+}
+
+// Redeem records usage of promo code reserved for order and saves usages, usage is recorded even if code is no longer
+// valid since order is already completed
+func (p *PromoCodes) Redeem(orderID string) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	reservation, ok := p.reservations[orderID]
+	if !ok {
+		return fmt.Errorf("no promo code reserved for order %s", orderID)
+	}
+	delete(p.reservations, orderID)
+
+	if p.usages[reservation.code] == nil {
+		p.usages[reservation.code] = make(map[int64]struct{})
+	}
+	p.usages[reservation.code][reservation.userID] = struct{}{}
+
+	return p.saveUsages()
+}
+
+// loadUsages reads usages file, usages file contains IDs of users that used promo code by code
+func (p *PromoCodes) loadUsages() error {
+	data, err := os.ReadFile(p.usagesFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("read promo code usages: %w", err)
+	}
+
+	var usages map[string][]int64
+	if err = json.Unmarshal(data, &usages); err != nil {
+		return fmt.Errorf("decode promo code usages: %w", err)
+	}
+
+	for code, userIDs := range usages {
+		code = normalizePromoCode(code)
+		if p.usages[code] == nil {
+			p.usages[code] = make(map[int64]struct{}, len(userIDs))
+		}
+		for _, userID := range userIDs {
+			p.usages[code][userID] = struct{}{}
+		}
+	}
+
+	return nil
+}
+
+// saveUsages writes usages to temporary file and replaces usages file with it, so usages file is never partially
+// written
+func (p *PromoCodes) saveUsages() error {
+	usages := make(map[string][]int64, len(p.usages))
+	for code, users := range p.usages {
+		userIDs := make([]int64, 0, len(users))
+		for userID := range users {
+			userIDs = append(userIDs, userID)
+		}
+		sort.Slice(userIDs, func(i, j int) bool { return userIDs[i] < userIDs[j] })
+		usages[code] = userIDs
+	}
+
+	data, err := json.MarshalIndent(usages, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode promo code usages: %w", err)
+	}
+
+	tmpFile := p.usagesFile + ".tmp"
+	if err = os.WriteFile(tmpFile, data, promoCodeUsagesPerm); err != nil {
+		return fmt.Errorf("write promo code usages: %w", err)
+	}
+
+	if err = os.Rename(tmpFile, p.usagesFile); err != nil {
+		return fmt.Errorf("replace promo code usages: %w", err)
+	}
+
+	return nil
+}
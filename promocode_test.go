@@ -0,0 +1,104 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPromoCodes(t *testing.T) {
+	usagesFile := filepath.Join(t.TempDir(), "usages.json")
+	promoCodes, err := LoadPromoCodes("promocodes.toml", usagesFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	promoCodes.codes["SYODO10"] = PromoCode{
+		Code:            "SYODO10",
+		Label:           "SYODO10",
+		DiscountPercent: 10,
+		UsageLimit:      2,
+		ExpiresAt:       "2023-12-31",
+	}
+
+	now := time.Date(2023, 3, 18, 12, 0, 0, 0, time.UTC)
+
+	promoCode, err := promoCodes.Validate(" syodo10 ", 1, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if discount := promoCode.Discount(50000); discount != 5000 {
+		t.Errorf("unexpected discount: %d", discount)
+	}
+
+	if _, err = promoCodes.Validate("unknown", 1, now); err == nil {
+		t.Error("expected unknown promo code error")
+	}
+
+	if _, err = promoCodes.Validate("SYODO10", 1, now.AddDate(1, 0, 0)); err == nil {
+		t.Error("expected expired promo code error")
+	}
+
+	if _, err = promoCodes.Reserve("SYODO10", 1, "000001", now); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = promoCodes.Reserve("SYODO10", 1, "000002", now); err == nil {
+		t.Error("expected already reserved promo code error")
+	}
+
+	if _, err = promoCodes.Reserve("SYODO10", 2, "000003", now); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = promoCodes.Validate("SYODO10", 3, now); err == nil {
+		t.Error("expected usage limit error counting reservations")
+	}
+
+	promoCodes.Release("000003")
+	if _, err = promoCodes.Validate("SYODO10", 3, now); err != nil {
+		t.Errorf("expected released reservation not to be counted: %s", err)
+	}
+
+	if err = promoCodes.Redeem("000001"); err != nil {
+		t.Fatal(err)
+	}
+	if err = promoCodes.Redeem("000001"); err == nil {
+		t.Error("expected error redeeming order without reservation")
+	}
+
+	reloaded, err := LoadPromoCodes("promocodes.toml", usagesFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reloaded.codes["SYODO10"] = promoCodes.codes["SYODO10"]
+
+	if _, err = reloaded.Validate("SYODO10", 1, now); err == nil {
+		t.Error("expected usage to persist after reload")
+	}
+	if _, err = reloaded.Validate("SYODO10", 2, now); err != nil {
+		t.Errorf("expected not redeemed reservation not to persist: %s", err)
+	}
+}
+
+func TestPromoCodeDiscount(t *testing.T) {
+	promoCode := PromoCode{DiscountAmount: 10000}
+
+	if discount := promoCode.Discount(50000); discount != 10000 {
+		t.Errorf("unexpected discount: %d", discount)
+	}
+
+	if discount := promoCode.Discount(5000); discount != 5000 {
+		t.Errorf("discount should not exceed sum, got: %d", discount)
+	}
+
+	discount := OrderDiscount{PromoCode: "SYODO10", PromoCodeDiscount: 10000}
+	if !discount.AppliedIn(PriceResponse{Discount: 10000}) {
+		t.Error("expected discount to be applied")
+	}
+	if discount.AppliedIn(PriceResponse{}) {
+		t.Error("expected discount ignored by Syodo not to be applied")
+	}
+	if !(OrderDiscount{}).AppliedIn(PriceResponse{}) {
+		t.Error("expected order without promo code to be accepted")
+	}
+}
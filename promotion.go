@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/go-playground/validator/v10"
+)
+
+const promotionDateLayout = "2006-01-02"
+
+// Promotion represents promotion that can be selected for the order
+type Promotion struct {
+	ID                string   `validate:"required"`
+	Label             string   `validate:"required"`
+	Labels            Labels   `validate:"dive,keys,required,endkeys,required"`
+	Emoji             string   `validate:"-"`
+	StartDate         string   `validate:"omitempty,datetime=2006-01-02"`
+	EndDate           string   `validate:"omitempty,datetime=2006-01-02"`
+	Weekdays          []string `validate:"dive,oneof=monday tuesday wednesday thursday friday saturday sunday"`
+	MinSum            int      `validate:"gte=0"`
+	DeliveryTypes     []string `validate:"dive,oneof=delivery self_pickup_1 self_pickup_2"`
+	CategoryIDs       []string `validate:"dive,required"`
+	MinCategoryAmount int      `validate:"gte=0"`
+}
+
+// Labels represents translations of label by locale
+type Labels map[string]string
+
+// localize returns translation of label for locale or label itself if there is no translation
+func (l Labels) localize(locale, label string) string {
+	if translation, ok := l[locale]; ok {
+		return translation
+	}
+	return label
+}
+
+// LocalizedLabel returns label of promotion for locale
+func (p Promotion) LocalizedLabel(locale string) string {
+	return p.Labels.localize(locale, p.Label)
+}
+
+// Promotions represents a map of promotion IDs and corresponding promotions
+type Promotions map[string]Promotion
+
+// LoadPromotions loads promotions from specified file
+func LoadPromotions(filename string) (Promotions, error) {
+	var promotionsFile struct {
+		Promotion []Promotion
+	}
+
+	_, err := toml.DecodeFile(filename, &promotionsFile)
+	if err != nil {
+		return nil, fmt.Errorf("decode promotions: %w", err)
+	}
+
+	validate := validator.New()
+	promotions := make(Promotions, len(promotionsFile.Promotion))
+
+	for _, promotion := range promotionsFile.Promotion {
+		if err = validate.Struct(promotion); err != nil {
+			return nil, fmt.Errorf("promotion %q validation: %w", promotion.ID, err)
+		}
+
+		if _, ok := promotions[promotion.ID]; ok {
+			return nil, fmt.Errorf("duplicate promotion %q", promotion.ID)
+		}
+
+		promotions[promotion.ID] = promotion
+	}
+
+	return promotions, nil
+}
+
+// Eligible checks if promotion with given ID can be applied to the order at specified time, no promotion is always
+// eligible
+//
+//nolint:cyclop
+func (p Promotions) Eligible(id string, order OrderRequest, now time.Time) error {
+	if id == "" {
+		return nil
+	}
+
+	promotion, ok := p[id]
+	if !ok {
+		return fmt.Errorf("unknown promotion %q", id)
+	}
+
+	today := now.Format(promotionDateLayout)
+	if promotion.StartDate != "" && today < promotion.StartDate {
+		return fmt.Errorf("promotion %q starts at %s", id, promotion.StartDate)
+	}
+	if promotion.EndDate != "" && today > promotion.EndDate {
+		return fmt.Errorf("promotion %q ended at %s", id, promotion.EndDate)
+	}
+
+	if len(promotion.Weekdays) != 0 && !containsFold(promotion.Weekdays, now.Weekday().String()) {
+		return fmt.Errorf("promotion %q is not available on %s", id, now.Weekday())
+	}
+
+	if len(promotion.DeliveryTypes) != 0 && !containsFold(promotion.DeliveryTypes, order.DeliveryType) {
+		return fmt.Errorf("promotion %q is not available for %q delivery", id, order.DeliveryType)
+	}
+
+	if sum := productsSum(order.Products); sum < promotion.MinSum {
+		return fmt.Errorf("promotion %q requires sum of at least %d, got %d", id, promotion.MinSum, sum)
+	}
+
+	if len(promotion.CategoryIDs) != 0 {
+		amount := 0
+		for _, product := range order.Products {
+			if containsFold(promotion.CategoryIDs, product.CategoryID) {
+				amount += product.Amount
+			}
+		}
+
+		if amount == 0 || amount < promotion.MinCategoryAmount {
+			return fmt.Errorf("promotion %q requires at least %d products from categories %s, got %d",
+				id, promotion.MinCategoryAmount, strings.Join(promotion.CategoryIDs, ", "), amount)
+		}
+	}
+
+	return nil
+}
+
+func productsSum(products []OrderProduct) int {
+	sum := 0
+	for _, product := range products {
+		sum += product.Price * product.Amount
+	}
+	return sum
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
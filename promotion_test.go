@@ -0,0 +1,108 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPromotions(t *testing.T) {
+	promotions, err := LoadPromotions("promotions.toml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	promotion, ok := promotions["4+1"]
+	if !ok {
+		t.Fatal("no 4+1 promotion")
+	}
+
+	if label := promotion.LocalizedLabel("en"); label != "4+1 promotion" {
+		t.Errorf("unexpected en label: %q", label)
+	}
+	if label := promotion.LocalizedLabel("uk"); label != promotion.Label {
+		t.Errorf("unexpected uk label: %q", label)
+	}
+}
+
+func TestPromotionsEligible(t *testing.T) {
+	promotions := Promotions{
+		"rolls": {
+			ID:                "rolls",
+			Label:             "Rolls",
+			CategoryIDs:       []string{"7"},
+			MinCategoryAmount: 2,
+		},
+		"weekend": {
+			ID:            "weekend",
+			Label:         "Weekend",
+			StartDate:     "2023-01-01",
+			EndDate:       "2023-12-31",
+			Weekdays:      []string{"saturday", "sunday"},
+			MinSum:        50000,
+			DeliveryTypes: []string{deliveryTypeDelivery},
+		},
+	}
+
+	saturday := time.Date(2023, 3, 18, 12, 0, 0, 0, time.UTC)
+	monday := time.Date(2023, 3, 20, 12, 0, 0, 0, time.UTC)
+	nextYear := time.Date(2024, 3, 16, 12, 0, 0, 0, time.UTC)
+
+	rolls := []OrderProduct{{CategoryID: "7", Price: 20000, Amount: 3}}
+	drinks := []OrderProduct{{CategoryID: "9", Price: 5000, Amount: 2}}
+
+	tests := []struct {
+		name     string
+		id       string
+		order    OrderRequest
+		now      time.Time
+		eligible bool
+	}{
+		{name: "no_promotion", id: "", now: monday, eligible: true},
+		{name: "unknown", id: "unknown", now: monday},
+		{name: "category", id: "rolls", order: OrderRequest{Products: rolls}, now: monday, eligible: true},
+		{name: "category_missing", id: "rolls", order: OrderRequest{Products: drinks}, now: monday},
+		{
+			name:     "weekend",
+			id:       "weekend",
+			order:    OrderRequest{Products: rolls, DeliveryType: deliveryTypeDelivery},
+			now:      saturday,
+			eligible: true,
+		},
+		{
+			name:  "weekend_weekday",
+			id:    "weekend",
+			order: OrderRequest{Products: rolls, DeliveryType: deliveryTypeDelivery},
+			now:   monday,
+		},
+		{
+			name:  "weekend_ended",
+			id:    "weekend",
+			order: OrderRequest{Products: rolls, DeliveryType: deliveryTypeDelivery},
+			now:   nextYear,
+		},
+		{
+			name:  "weekend_self_pickup",
+			id:    "weekend",
+			order: OrderRequest{Products: rolls, DeliveryType: "self_pickup_1"},
+			now:   saturday,
+		},
+		{
+			name:  "weekend_min_sum",
+			id:    "weekend",
+			order: OrderRequest{Products: drinks, DeliveryType: deliveryTypeDelivery},
+			now:   saturday,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := promotions.Eligible(tt.id, tt.order, tt.now)
+			if tt.eligible && err != nil {
+				t.Errorf("expected eligible, got: %s", err)
+			}
+			if !tt.eligible && err == nil {
+				t.Error("expected not eligible")
+			}
+		})
+	}
+}
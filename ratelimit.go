@@ -0,0 +1,163 @@
+package main
+
+import (
+	"math"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/valyala/fasthttp"
+	"golang.org/x/time/rate"
+
+	"github.com/mymmrac/syodo-telegram-bot/config"
+)
+
+// rateLimiterCleanupInterval represents how often limiters of inactive keys are removed
+const rateLimiterCleanupInterval = time.Minute
+
+// RateLimiter represents token bucket rate limiter with separate bucket for each key
+type RateLimiter[K comparable] struct {
+	limit       rate.Limit
+	burst       int
+	idleTimeout time.Duration
+
+	lock        sync.Mutex
+	limiters    map[K]*keyLimiter
+	lastCleanup time.Time
+}
+
+type keyLimiter struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// NewRateLimiter creates new RateLimiter, each key gets one token per interval with up to burst tokens stored, nil
+// is returned if limit is disabled
+func NewRateLimiter[K comparable](cfg config.Limit) *RateLimiter[K] {
+	if cfg.Interval == 0 {
+		return nil
+	}
+
+	return &RateLimiter[K]{
+		limit: rate.Every(cfg.Interval),
+		burst: cfg.Burst,
+		// Full bucket is restored after this time, so limiter can be recreated without changing behavior
+		idleTimeout: cfg.Interval * time.Duration(cfg.Burst),
+		limiters:    make(map[K]*keyLimiter),
+	}
+}
+
+// Allow reports if request with key is allowed, if not, returns time after which request can be retried, nil
+// limiter allows all requests
+func (l *RateLimiter[K]) Allow(key K, now time.Time) (bool, time.Duration) {
+	if l == nil {
+		return true, 0
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.cleanup(now)
+
+	kl, ok := l.limiters[key]
+	if !ok {
+		kl = &keyLimiter{limiter: rate.NewLimiter(l.limit, l.burst)}
+		l.limiters[key] = kl
+	}
+	kl.lastSeen = now
+
+	reservation := kl.limiter.ReserveN(now, 1)
+	if !reservation.OK() {
+		return false, rate.InfDuration
+	}
+
+	if delay := reservation.DelayFrom(now); delay > 0 {
+		reservation.CancelAt(now)
+		return false, delay
+	}
+
+	return true, 0
+}
+
+// cleanup removes limiters of keys that were not seen longer than idle timeout
+func (l *RateLimiter[K]) cleanup(now time.Time) {
+	if now.Sub(l.lastCleanup) < rateLimiterCleanupInterval {
+		return
+	}
+	l.lastCleanup = now
+
+	for key, kl := range l.limiters {
+		if now.Sub(kl.lastSeen) > l.idleTimeout {
+			delete(l.limiters, key)
+		}
+	}
+}
+
+// remoteIP returns IP of client, if real IP header is configured and request came from trusted proxy, client IP is
+// taken from header instead of remote address, for list of addresses (e.g. X-Forwarded-For) the last one not added
+// by trusted proxy is used
+func (h *Handler) remoteIP(ctx *fasthttp.RequestCtx) string {
+	remoteIP := ctx.RemoteIP().String()
+
+	cfg := h.config().RateLimit
+	if cfg.RealIPHeader == "" || !isTrustedProxy(remoteIP, cfg.TrustedProxies) {
+		return remoteIP
+	}
+
+	hops := strings.Split(string(ctx.Request.Header.Peek(cfg.RealIPHeader)), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			break
+		}
+
+		if i == 0 || !isTrustedProxy(ip.String(), cfg.TrustedProxies) {
+			return ip.String()
+		}
+	}
+
+	return remoteIP
+}
+
+// isTrustedProxy reports if IP matches any of trusted proxy IPs or CIDRs
+func isTrustedProxy(ip string, trustedProxies []string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+
+	for _, proxy := range trustedProxies {
+		if prefix, err := netip.ParsePrefix(proxy); err == nil {
+			if prefix.Contains(addr) {
+				return true
+			}
+			continue
+		}
+
+		if proxyAddr, err := netip.ParseAddr(proxy); err == nil && proxyAddr.Unmap() == addr {
+			return true
+		}
+	}
+
+	return false
+}
+
+const orderErrorRateLimited = "rate_limited"
+
+// writeRateLimited responds with too many requests status and time after which request can be retried
+func (h *Handler) writeRateLimited(ctx *fasthttp.RequestCtx, retryAfter time.Duration) {
+	h.metrics.orderFailed(failureRateLimited)
+
+	orderErr := orderError{
+		Error: orderErrorRateLimited,
+	}
+	if retryAfter != rate.InfDuration {
+		orderErr.RetryAfter = int(math.Ceil(retryAfter.Seconds()))
+		ctx.Response.Header.Set(fasthttp.HeaderRetryAfter, strconv.Itoa(orderErr.RetryAfter))
+	}
+
+	h.writeError(ctx, fasthttp.StatusTooManyRequests, orderErr)
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/kataras/golog"
+	"github.com/valyala/fasthttp"
+
+	"github.com/mymmrac/syodo-telegram-bot/config"
+	"github.com/mymmrac/syodo-telegram-bot/logger"
+)
+
+func TestRateLimiter(t *testing.T) {
+	limiter := NewRateLimiter[int64](config.Limit{Interval: 10 * time.Second, Burst: 2})
+	now := time.Date(2023, 3, 18, 12, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 2; i++ {
+		if ok, _ := limiter.Allow(1, now); !ok {
+			t.Fatalf("expected request %d within burst to be allowed", i+1)
+		}
+	}
+
+	ok, retryAfter := limiter.Allow(1, now)
+	if ok {
+		t.Fatal("expected request over burst to be limited")
+	}
+	if retryAfter != 10*time.Second {
+		t.Errorf("expected retry after 10s, got %s", retryAfter)
+	}
+
+	if ok, _ = limiter.Allow(2, now); !ok {
+		t.Error("expected other key to have own limit")
+	}
+
+	if ok, _ = limiter.Allow(1, now.Add(10*time.Second)); !ok {
+		t.Error("expected request to be allowed after interval")
+	}
+
+	var disabled *RateLimiter[string]
+	if ok, _ = disabled.Allow("127.0.0.1", now); !ok {
+		t.Error("expected disabled limiter to allow requests")
+	}
+	if NewRateLimiter[string](config.Limit{}) != nil {
+		t.Error("expected zero interval to disable limiter")
+	}
+}
+
+func TestRemoteIP(t *testing.T) {
+	tests := []struct {
+		name       string
+		remoteAddr string
+		header     string
+		expected   string
+	}{
+		{name: "no_header", remoteAddr: "10.0.0.1", expected: "10.0.0.1"},
+		{name: "real_ip", remoteAddr: "10.0.0.1", header: "203.0.113.7", expected: "203.0.113.7"},
+		{name: "untrusted_proxy", remoteAddr: "198.51.100.1", header: "203.0.113.7", expected: "198.51.100.1"},
+		{name: "forwarded_for", remoteAddr: "10.0.0.1", header: "1.1.1.1, 203.0.113.7", expected: "203.0.113.7"},
+		{name: "trusted_hops", remoteAddr: "10.0.0.1", header: "203.0.113.7, 10.0.0.2", expected: "203.0.113.7"},
+		{name: "only_trusted", remoteAddr: "10.0.0.1", header: "10.0.0.3, 10.0.0.2", expected: "10.0.0.3"},
+		{name: "invalid", remoteAddr: "10.0.0.1", header: "1.1.1.1, unknown", expected: "10.0.0.1"},
+	}
+
+	h := &Handler{}
+	h.cfg.Store(&config.Config{RateLimit: config.RateLimit{
+		RealIPHeader:   fasthttp.HeaderXForwardedFor,
+		TrustedProxies: []string{"10.0.0.0/8"},
+	}})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := &fasthttp.RequestCtx{}
+			ctx.Init(&fasthttp.Request{}, &net.TCPAddr{IP: net.ParseIP(tt.remoteAddr)}, nil)
+			if tt.header != "" {
+				ctx.Request.Header.Set(fasthttp.HeaderXForwardedFor, tt.header)
+			}
+
+			if ip := h.remoteIP(ctx); ip != tt.expected {
+				t.Errorf("expected IP %q, got %q", tt.expected, ip)
+			}
+		})
+	}
+}
+
+func TestWriteRateLimited(t *testing.T) {
+	h := &Handler{
+		log:     logger.NewLog(golog.New()),
+		metrics: NewMetrics(),
+	}
+
+	ctx := &fasthttp.RequestCtx{}
+	h.writeRateLimited(ctx, 1500*time.Millisecond)
+
+	if ctx.Response.StatusCode() != fasthttp.StatusTooManyRequests {
+		t.Errorf("unexpected status: %d", ctx.Response.StatusCode())
+	}
+	if retryAfter := string(ctx.Response.Header.Peek(fasthttp.HeaderRetryAfter)); retryAfter != "2" {
+		t.Errorf("unexpected retry after: %q", retryAfter)
+	}
+
+	var orderErr orderError
+	if err := json.Unmarshal(ctx.Response.Body(), &orderErr); err != nil {
+		t.Fatal(err)
+	}
+	if orderErr.Error != orderErrorRateLimited || orderErr.RetryAfter != 2 {
+		t.Errorf("unexpected error: %+v", orderErr)
+	}
+}
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mymmrac/syodo-telegram-bot/config"
+)
+
+const (
+	scheduleDateLayout  = "2006-01-02"
+	scheduleClockLayout = "15:04"
+
+	// nextOpeningSearchDays is a number of days to look ahead for next opening
+	nextOpeningSearchDays = 31
+)
+
+// workingDay represents working hours of a single day as durations since midnight
+type workingDay struct {
+	closed    bool
+	openTime  time.Duration
+	closeTime time.Duration
+}
+
+// WorkingHours represents Syodo working hours, holidays and ad-hoc closures
+type WorkingHours struct {
+	cfg      config.Schedule
+	timezone *time.Location
+	days     [7]workingDay
+	holidays map[string]struct{}
+
+	closed      bool
+	closeReason string
+	closedLock  sync.RWMutex
+}
+
+// NewWorkingHours creates new WorkingHours
+func NewWorkingHours(cfg config.Schedule, timezone *time.Location) (*WorkingHours, error) {
+	defaultDay, err := newWorkingDay(config.WorkingDay{
+		OpenTime:  cfg.OpenTime,
+		CloseTime: cfg.CloseTime,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	w := &WorkingHours{
+		cfg:      cfg,
+		timezone: timezone,
+		holidays: make(map[string]struct{}, len(cfg.Holidays)),
+	}
+
+	for weekday := range w.days {
+		w.days[weekday] = defaultDay
+	}
+
+	for name, dayCfg := range cfg.Weekdays {
+		weekday, ok := parseWeekday(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown weekday: %q", name)
+		}
+
+		if dayCfg.OpenTime == "" {
+			dayCfg.OpenTime = cfg.OpenTime
+		}
+		if dayCfg.CloseTime == "" {
+			dayCfg.CloseTime = cfg.CloseTime
+		}
+
+		w.days[weekday], err = newWorkingDay(dayCfg)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+	}
+
+	for _, holiday := range cfg.Holidays {
+		w.holidays[holiday] = struct{}{}
+	}
+
+	return w, nil
+}
+
+func newWorkingDay(cfg config.WorkingDay) (workingDay, error) {
+	if cfg.Closed {
+		return workingDay{closed: true}, nil
+	}
+
+	openTime, err := parseClock(cfg.OpenTime)
+	if err != nil {
+		return workingDay{}, fmt.Errorf("open time: %w", err)
+	}
+
+	closeTime, err := parseClock(cfg.CloseTime)
+	if err != nil {
+		return workingDay{}, fmt.Errorf("close time: %w", err)
+	}
+
+	if closeTime <= openTime {
+		return workingDay{}, fmt.Errorf("close time %s is not after open time %s", cfg.CloseTime, cfg.OpenTime)
+	}
+
+	return workingDay{
+		openTime:  openTime,
+		closeTime: closeTime,
+	}, nil
+}
+
+// parseClock returns time of day as duration since midnight
+func parseClock(clock string) (time.Duration, error) {
+	t, err := time.Parse(scheduleClockLayout, clock)
+	if err != nil {
+		return 0, fmt.Errorf("parse %q: %w", clock, err)
+	}
+
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+func parseWeekday(name string) (time.Weekday, bool) {
+	for weekday := time.Sunday; weekday <= time.Saturday; weekday++ {
+		if strings.EqualFold(weekday.String(), name) {
+			return weekday, true
+		}
+	}
+	return 0, false
+}
+
+// Close closes Syodo until Open is called
+func (w *WorkingHours) Close(reason string) {
+	w.closedLock.Lock()
+	defer w.closedLock.Unlock()
+
+	w.closed = true
+	w.closeReason = reason
+}
+
+// Open cancels closure made by Close
+func (w *WorkingHours) Open() {
+	w.closedLock.Lock()
+	defer w.closedLock.Unlock()
+
+	w.closed = false
+	w.closeReason = ""
+}
+
+// Closure returns reason of ad-hoc closure and true if Syodo is closed by Close
+func (w *WorkingHours) Closure() (string, bool) {
+	w.closedLock.RLock()
+	defer w.closedLock.RUnlock()
+
+	return w.closeReason, w.closed
+}
+
+// IsOpen checks if Syodo is open at specified time
+func (w *WorkingHours) IsOpen(t time.Time) bool {
+	if _, closed := w.Closure(); closed {
+		return false
+	}
+
+	return w.isWorkingTime(t)
+}
+
+// NextOpening returns the nearest time starting from specified one when Syodo is open, zero time is returned if it's
+// closed by Close or no opening was found
+func (w *WorkingHours) NextOpening(t time.Time) time.Time {
+	if _, closed := w.Closure(); closed {
+		return time.Time{}
+	}
+
+	t = t.In(w.timezone)
+	for i := 0; i < nextOpeningSearchDays; i++ {
+		day := midnight(t).AddDate(0, 0, i)
+		if !w.isWorkingDay(day) {
+			continue
+		}
+
+		hours := w.days[day.Weekday()]
+		openAt := atClock(day, hours.openTime)
+		closeAt := atClock(day, hours.closeTime)
+
+		switch {
+		case t.Before(openAt):
+			return openAt
+		case t.Before(closeAt):
+			return t
+		}
+	}
+
+	return time.Time{}
+}
+
+// ScheduledTime parses and validates scheduled delivery date & time, zero time is returned if order has no
+// scheduled time (delivered as soon as possible)
+func (w *WorkingHours) ScheduledTime(date, clock string, now time.Time) (time.Time, error) {
+	if date == "" && clock == "" {
+		return time.Time{}, nil
+	}
+
+	scheduledAt, err := time.ParseInLocation(scheduleDateLayout+" "+scheduleClockLayout, date+" "+clock, w.timezone)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("parse scheduled time: %w", err)
+	}
+
+	now = now.In(w.timezone)
+	if scheduledAt.Before(now.Add(w.cfg.MinPreorderTime)) {
+		return time.Time{}, fmt.Errorf("scheduled time %s is earlier than allowed", scheduledAt)
+	}
+
+	if lastDay := now.AddDate(0, 0, w.cfg.MaxPreorderDays); date > lastDay.Format(scheduleDateLayout) {
+		return time.Time{}, fmt.Errorf("scheduled time %s is later than allowed", scheduledAt)
+	}
+
+	if !w.isWorkingTime(scheduledAt) {
+		return time.Time{}, errors.New("scheduled time is outside of working hours")
+	}
+
+	return scheduledAt, nil
+}
+
+// isWorkingDay checks if day of specified time is not a holiday or day off
+func (w *WorkingHours) isWorkingDay(t time.Time) bool {
+	if _, ok := w.holidays[t.Format(scheduleDateLayout)]; ok {
+		return false
+	}
+
+	return !w.days[t.Weekday()].closed
+}
+
+// isWorkingTime checks if time is within working hours of its day, close time itself is not working time
+func (w *WorkingHours) isWorkingTime(t time.Time) bool {
+	t = t.In(w.timezone)
+	if !w.isWorkingDay(t) {
+		return false
+	}
+
+	hours := w.days[t.Weekday()]
+	sinceMidnight := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+	return sinceMidnight >= hours.openTime && sinceMidnight < hours.closeTime
+}
+
+func midnight(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
+
+// atClock returns time of the same day with specified time of day, wall clock is used, so it's correct on DST changes
+func atClock(t time.Time, clock time.Duration) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, int(clock/time.Hour), int(clock%time.Hour/time.Minute), 0, 0, t.Location())
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mymmrac/syodo-telegram-bot/config"
+)
+
+func TestWorkingHours(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Kiev")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	schedule, err := NewWorkingHours(config.Schedule{
+		OpenTime:  "10:00",
+		CloseTime: "22:00",
+		Weekdays: map[string]config.WorkingDay{
+			"sunday": {OpenTime: "11:00"},
+			"monday": {Closed: true},
+		},
+		Holidays:        []string{"2023-03-21"},
+		MinPreorderTime: time.Hour,
+		MaxPreorderDays: 7,
+	}, loc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	saturdayNight := time.Date(2023, 3, 18, 23, 30, 0, 0, loc)
+	saturdayNoon := time.Date(2023, 3, 18, 12, 0, 0, 0, loc)
+
+	if !schedule.IsOpen(saturdayNoon) {
+		t.Error("expected open at saturday noon")
+	}
+	if schedule.IsOpen(saturdayNight) {
+		t.Error("expected closed at saturday night")
+	}
+	if schedule.IsOpen(time.Date(2023, 3, 18, 22, 0, 0, 0, loc)) {
+		t.Error("expected closed at closing time")
+	}
+	if !schedule.IsOpen(time.Date(2023, 3, 18, 21, 59, 0, 0, loc)) {
+		t.Error("expected open at last minute before closing")
+	}
+
+	// Sunday opens later
+	if next := schedule.NextOpening(saturdayNight); !next.Equal(time.Date(2023, 3, 19, 11, 0, 0, 0, loc)) {
+		t.Errorf("unexpected next opening: %s", next)
+	}
+
+	// Monday is day off and Tuesday is a holiday
+	sundayNight := time.Date(2023, 3, 19, 23, 0, 0, 0, loc)
+	if next := schedule.NextOpening(sundayNight); !next.Equal(time.Date(2023, 3, 22, 10, 0, 0, 0, loc)) {
+		t.Errorf("unexpected next opening: %s", next)
+	}
+
+	if next := schedule.NextOpening(saturdayNoon); !next.Equal(saturdayNoon) {
+		t.Errorf("expected to be open now, got: %s", next)
+	}
+
+	schedule.Close("Technical works")
+	if schedule.IsOpen(saturdayNoon) {
+		t.Error("expected closed after close")
+	}
+	if reason, closed := schedule.Closure(); !closed || reason != "Technical works" {
+		t.Errorf("unexpected closure: %q, %t", reason, closed)
+	}
+	if next := schedule.NextOpening(saturdayNoon); !next.IsZero() {
+		t.Errorf("expected no next opening, got: %s", next)
+	}
+
+	schedule.Open()
+	if !schedule.IsOpen(saturdayNoon) {
+		t.Error("expected open after open")
+	}
+}
+
+func TestWorkingHoursScheduledTime(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Kiev")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	schedule, err := NewWorkingHours(config.Schedule{
+		OpenTime:        "10:00",
+		CloseTime:       "22:00",
+		MinPreorderTime: time.Hour,
+		MaxPreorderDays: 2,
+	}, loc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2023, 3, 18, 12, 0, 0, 0, loc)
+
+	tests := []struct {
+		name  string
+		date  string
+		clock string
+		valid bool
+	}{
+		{name: "asap", valid: true},
+		{name: "valid", date: "2023-03-18", clock: "18:00", valid: true},
+		{name: "last_day", date: "2023-03-20", clock: "21:00", valid: true},
+		{name: "too_early", date: "2023-03-18", clock: "12:30"},
+		{name: "too_late", date: "2023-03-21", clock: "12:00"},
+		{name: "closed", date: "2023-03-18", clock: "23:00"},
+		{name: "closing_time", date: "2023-03-18", clock: "22:00"},
+		{name: "before_closing", date: "2023-03-18", clock: "21:59", valid: true},
+		{name: "bad_format", date: "18.03.2023", clock: "18:00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := schedule.ScheduledTime(tt.date, tt.clock, now)
+			if tt.valid && err != nil {
+				t.Errorf("expected valid, got: %s", err)
+			}
+			if !tt.valid && err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
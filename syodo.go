@@ -1,13 +1,16 @@
 package main
 
 import (
+	"context"
 	"crypto/sha1" //nolint:gosec
 	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/url"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/mymmrac/telego"
@@ -22,6 +25,9 @@ const (
 	contentTypeJSON = "application/json"
 	contentTypeURL  = "application/x-www-form-urlencoded"
 	authHeader      = "x-api-key"
+
+	// correlationIDHeader is used to pass correlation ID to Syodo API and return it to web app
+	correlationIDHeader = "X-Correlation-ID"
 )
 
 const (
@@ -30,31 +36,54 @@ const (
 	shippingTypeDelivery   = "Доставка"
 	shippingTypeSelfPickup = "Самовивіз"
 
-	promo4Plus1 = "4+1"
+	paymentMethodOnline = "online"
+	paymentMethodCash   = "cash"
+	paymentMethodCard   = "card"
+
+	paymentTypeOnline = "Онлайн"
+	paymentTypeCash   = "Готівка"
+	paymentTypeCard   = "Картка кур'єру"
 )
 
 // SyodoService represents a type to interact with Syodo API
 type SyodoService struct {
-	cfg      *config.Config
+	cfg      atomic.Pointer[config.Config]
 	log      logger.Logger
 	client   *fasthttp.Client
 	timezone *time.Location
+	metrics  *Metrics
 }
 
+// timezoneName represents timezone of Syodo
+const timezoneName = "Europe/Kiev"
+
 // NewSyodoService creates new SyodoService
-func NewSyodoService(cfg *config.Config, log logger.Logger) *SyodoService {
-	loc, err := time.LoadLocation("Europe/Kiev")
+func NewSyodoService(cfg *config.Config, log logger.Logger, metrics *Metrics) *SyodoService {
+	loc, err := time.LoadLocation(timezoneName)
 	assert(err == nil, fmt.Errorf("load timezone: %w", err))
 
-	return &SyodoService{
-		cfg:      cfg,
+	s := &SyodoService{
 		log:      log,
 		client:   &fasthttp.Client{},
 		timezone: loc,
+		metrics:  metrics,
 	}
+	s.SetConfig(cfg)
+
+	return s
+}
+
+// SetConfig replaces config used for following requests, used on reload
+func (s *SyodoService) SetConfig(cfg *config.Config) {
+	s.cfg.Store(cfg)
+}
+
+// config returns current config
+func (s *SyodoService) config() *config.Config {
+	return s.cfg.Load()
 }
 
-func (s *SyodoService) callJSON(path, method string, data, result any) error {
+func (s *SyodoService) callJSON(ctx context.Context, path, method string, data, result any) error {
 	var jsonData []byte
 	if data != nil {
 		var err error
@@ -64,18 +93,18 @@ func (s *SyodoService) callJSON(path, method string, data, result any) error {
 		}
 	}
 
-	return s.call(path, method, contentTypeJSON, jsonData, result)
+	return s.call(ctx, path, method, contentTypeJSON, jsonData, result)
 }
 
-func (s *SyodoService) callURL(path, method, data string, result any) error {
-	return s.call(path, method, contentTypeURL, []byte(data), result)
+func (s *SyodoService) callURL(ctx context.Context, path, method, data string, result any) error {
+	return s.call(ctx, path, method, contentTypeURL, []byte(data), result)
 }
 
-func (s *SyodoService) call(path, method, contentType string, data []byte, result any) error {
+func (s *SyodoService) call(ctx context.Context, path, method, contentType string, data []byte, result any) error {
 	req := fasthttp.AcquireRequest()
 	defer fasthttp.ReleaseRequest(req)
 
-	apiURL, err := url.JoinPath(s.cfg.App.SyodoAPIURL, path)
+	apiURL, err := url.JoinPath(s.config().App.SyodoAPIURL, path)
 	if err != nil {
 		return fmt.Errorf("join path: %w", err)
 	}
@@ -84,7 +113,10 @@ func (s *SyodoService) call(path, method, contentType string, data []byte, resul
 
 	req.Header.SetMethod(method)
 	req.Header.SetContentType(contentType)
-	req.Header.Set(authHeader, s.cfg.App.SyodoAPIKey)
+	req.Header.Set(authHeader, s.config().App.SyodoAPIKey)
+	if id := logger.CorrelationID(ctx); id != "" {
+		req.Header.Set(correlationIDHeader, id)
+	}
 
 	if data != nil {
 		req.SetBodyRaw(data)
@@ -93,9 +125,22 @@ func (s *SyodoService) call(path, method, contentType string, data []byte, resul
 	resp := fasthttp.AcquireResponse()
 	defer fasthttp.ReleaseResponse(resp)
 
-	if err = s.client.DoTimeout(req, resp, s.cfg.Settings.RequestTimeout); err != nil {
+	start := time.Now()
+	err = s.client.DoTimeout(req, resp, s.config().Settings.RequestTimeout)
+	duration := time.Since(start)
+
+	if err != nil {
+		s.metrics.syodoCall(path, 0, duration.Seconds())
 		return fmt.Errorf("call syodo: %w", err)
 	}
+	s.metrics.syodoCall(path, resp.StatusCode(), duration.Seconds())
+
+	log := logger.WithFields(logger.WithContext(s.log, ctx), logger.Fields{
+		"path":     path,
+		"status":   resp.StatusCode(),
+		"duration": duration.String(),
+	})
+	log.Debugf("Syodo API %s %s", method, path)
 
 	if statusCode := resp.StatusCode(); statusCode != fasthttp.StatusOK {
 		return fmt.Errorf("call syodo bad status: %d", statusCode)
@@ -103,7 +148,7 @@ func (s *SyodoService) call(path, method, contentType string, data []byte, resul
 
 	if result != nil {
 		body := resp.Body()
-		s.log.Debugf("Request to %q: data: %s, response %s", path, string(data), string(body))
+		log.Debugf("Request to %q: data: %s, response %s", path, string(data), string(body))
 
 		if err = json.Unmarshal(body, result); err != nil {
 			return fmt.Errorf("decode result: %w", err)
@@ -113,6 +158,20 @@ func (s *SyodoService) call(path, method, contentType string, data []byte, resul
 	return nil
 }
 
+// Ping checks that Syodo API is reachable, any response that is not a server error is considered as success
+func (s *SyodoService) Ping() error {
+	statusCode, _, err := s.client.GetTimeout(nil, s.config().App.SyodoAPIURL, s.config().Health.SyodoTimeout)
+	if err != nil {
+		return fmt.Errorf("call syodo: %w", err)
+	}
+
+	if statusCode >= fasthttp.StatusInternalServerError {
+		return fmt.Errorf("call syodo bad status: %d", statusCode)
+	}
+
+	return nil
+}
+
 type orderDTO struct {
 	ID         string `json:"id"`
 	CategoryID string `json:"category_id"`
@@ -134,9 +193,24 @@ type priceRequest struct {
 	Order             []orderDTO  `json:"order"`
 	DeliveryDetails   deliveryDTO `json:"deliveryDetails"`
 	SelectedPromotion string      `json:"selectedPromotion"`
+	PromoCode         string      `json:"promoCode,omitempty"`
+	PromoCodeDiscount int         `json:"promoCodeDiscount,omitempty"`
+}
+
+// OrderDiscount represents discount selected for order, promotion and promo code can't be used together, promo code
+// discount is calculated by bot and passed to Syodo, orders priced by Syodo without it are rejected
+type OrderDiscount struct {
+	Promotion         string
+	PromoCode         string
+	PromoCodeDiscount int
+}
+
+// AppliedIn reports if promo code discount is included in prices calculated by Syodo
+func (d OrderDiscount) AppliedIn(price PriceResponse) bool {
+	return price.Discount >= d.PromoCodeDiscount
 }
 
-// PriceResponse represents calculated price of order
+// PriceResponse represents calculated price of order, discount includes discount of promotion or promo code
 type PriceResponse struct {
 	Delivery    int    `json:"delivery"`
 	Discount    int    `json:"discount"`
@@ -145,19 +219,21 @@ type PriceResponse struct {
 
 // CalculatePriceDelivery returns calculated price depending on order details and delivery zone
 func (s *SyodoService) CalculatePriceDelivery(
-	products []OrderProduct, location maps.LatLng, promotion string,
+	ctx context.Context, products []OrderProduct, location maps.LatLng, discount OrderDiscount,
 ) (PriceResponse, error) {
-	return s.calculatePrice(products, shippingTypeDelivery, location, promotion)
+	return s.calculatePrice(ctx, products, shippingTypeDelivery, location, discount)
 }
 
 // CalculatePriceSelfPickup returns calculated price depending on order details
-func (s *SyodoService) CalculatePriceSelfPickup(products []OrderProduct, promotion string) (PriceResponse, error) {
-	resp, err := s.calculatePrice(products, shippingTypeSelfPickup, maps.LatLng{}, promotion)
+func (s *SyodoService) CalculatePriceSelfPickup(
+	ctx context.Context, products []OrderProduct, discount OrderDiscount,
+) (PriceResponse, error) {
+	resp, err := s.calculatePrice(ctx, products, shippingTypeSelfPickup, maps.LatLng{}, discount)
 	return resp, err
 }
 
 func (s *SyodoService) calculatePrice(
-	products []OrderProduct, shippingType string, location maps.LatLng, promotion string,
+	ctx context.Context, products []OrderProduct, shippingType string, location maps.LatLng, discount OrderDiscount,
 ) (PriceResponse, error) {
 	requestOrder := orderToDTO(products)
 
@@ -170,11 +246,13 @@ func (s *SyodoService) calculatePrice(
 				Lng: location.Lng,
 			},
 		},
-		SelectedPromotion: promotion,
+		SelectedPromotion: discount.Promotion,
+		PromoCode:         discount.PromoCode,
+		PromoCodeDiscount: discount.PromoCodeDiscount,
 	}
 
 	var priceResp PriceResponse
-	if err := s.callJSON("/price", fasthttp.MethodPost, priceReq, &priceResp); err != nil {
+	if err := s.callJSON(ctx, "/price", fasthttp.MethodPost, priceReq, &priceResp); err != nil {
 		return PriceResponse{}, fmt.Errorf("price API: %w", err)
 	}
 
@@ -220,6 +298,29 @@ type paymentDTO struct {
 	RestFrom      string `json:"restFrom"`
 }
 
+func paymentToDTO(order OrderRequest) paymentDTO {
+	switch order.PaymentMethod {
+	case paymentMethodCash:
+		var restFrom string
+		if order.ChangeFrom > 0 {
+			restFrom = strconv.Itoa(order.ChangeFrom)
+		}
+
+		return paymentDTO{
+			PaymentMethod: paymentTypeCash,
+			RestFrom:      restFrom,
+		}
+	case paymentMethodCard:
+		return paymentDTO{
+			PaymentMethod: paymentTypeCard,
+		}
+	default:
+		return paymentDTO{
+			PaymentMethod: paymentTypeOnline,
+		}
+	}
+}
+
 type infoDTO struct {
 	NoNapkins       bool `json:"noNapkins"`
 	Persons         int  `json:"persons"`
@@ -236,6 +337,8 @@ type checkoutRequest struct {
 	Info              infoDTO            `json:"info"`
 	OrderDetails      []orderDTO         `json:"orderDetails"`
 	SelectedPromotion string             `json:"selectedPromotion"`
+	PromoCode         string             `json:"promoCode,omitempty"`
+	PromoCodeDiscount int                `json:"promoCodeDiscount,omitempty"`
 }
 
 type checkoutResponse struct {
@@ -267,7 +370,7 @@ type checkoutDTO struct {
 // Checkout registers order in Syodo services
 //
 //nolint:cyclop
-func (s *SyodoService) Checkout(order *OrderDetails) error {
+func (s *SyodoService) Checkout(ctx context.Context, order *OrderDetails) error {
 	if order == nil {
 		return errors.New("nil order checkout")
 	}
@@ -287,6 +390,18 @@ func (s *SyodoService) Checkout(order *OrderDetails) error {
 		pickupLocation = "2"
 	}
 
+	var scheduledDate, scheduledTime string
+	if !order.ScheduledAt.IsZero() {
+		scheduledAt := order.ScheduledAt.In(s.timezone)
+		scheduledDate = scheduledAt.Format(scheduleDateLayout)
+		scheduledTime = scheduledAt.Format(scheduleClockLayout)
+	}
+
+	comment := order.Request.Comment
+	if order.Request.PromoCode != "" {
+		comment = strings.TrimSpace(comment + "\nПромокод: " + order.Request.PromoCode)
+	}
+
 	checkoutReq := checkoutRequest{
 		Description: fmt.Sprintf("Замовлення з Telegram: %s, #%s",
 			time.Now().In(s.timezone).Format("2006-01-02 15:04"), order.OrderID),
@@ -298,8 +413,10 @@ func (s *SyodoService) Checkout(order *OrderDetails) error {
 		},
 		DeliveryDetails: deliveryDetailsDTO{
 			Type:        deliveryType,
+			Date:        scheduledDate,
+			Time:        scheduledTime,
 			DontCall:    order.Request.DoNotCall,
-			Comments:    order.Request.Comment,
+			Comments:    comment,
 			Address:     order.Request.Address + ", м. " + order.Request.City,
 			Entrance:    order.Request.Entrance,
 			Apt:         order.Request.Apartment,
@@ -311,9 +428,7 @@ func (s *SyodoService) Checkout(order *OrderDetails) error {
 			},
 			PickupLocation: pickupLocation,
 		},
-		PaymentDetails: paymentDTO{
-			PaymentMethod: "Онлайн",
-		},
+		PaymentDetails: paymentToDTO(order.Request),
 		Info: infoDTO{
 			NoNapkins:       order.Request.NoNapkins,
 			Persons:         order.Request.CutleryCount,
@@ -321,15 +436,17 @@ func (s *SyodoService) Checkout(order *OrderDetails) error {
 		},
 		OrderDetails:      orderToDTO(order.Request.Products),
 		SelectedPromotion: order.Request.Promotion,
+		PromoCode:         order.Request.PromoCode,
+		PromoCodeDiscount: order.PromoCodeDiscount,
 	}
 
 	var checkoutResp checkoutResponse
-	if err := s.callJSON("/payments/checkout", fasthttp.MethodPost, checkoutReq, &checkoutResp); err != nil {
+	if err := s.callJSON(ctx, "/payments/checkout", fasthttp.MethodPost, checkoutReq, &checkoutResp); err != nil {
 		return fmt.Errorf("checkout API: %w", err)
 	}
 
-	if !s.cfg.Settings.TestMode {
-		signature := sign(checkoutResp.Data, s.cfg.App.LiqPayPrivetKeyEnv)
+	if !s.config().Settings.TestMode {
+		signature := sign(checkoutResp.Data, s.config().App.LiqPayPrivetKeyEnv)
 		if signature != checkoutResp.Signature {
 			return fmt.Errorf("checkout signature does not match")
 		}
@@ -339,7 +456,7 @@ func (s *SyodoService) Checkout(order *OrderDetails) error {
 	if err != nil {
 		return fmt.Errorf("decode data: %w", err)
 	}
-	s.log.Debugf("Checkout data: %s", string(data))
+	logger.WithContext(s.log, ctx).Debugf("Checkout data: %s", string(data))
 
 	var checkout checkoutDTO
 	if err = json.Unmarshal(data, &checkout); err != nil {
@@ -347,7 +464,7 @@ func (s *SyodoService) Checkout(order *OrderDetails) error {
 	}
 
 	order.ExternalOrderID = checkout.OrderID
-	if s.cfg.Settings.TestMode {
+	if s.config().Settings.TestMode {
 		order.OrderURL = strings.Replace(checkout.ResultURL, "APP_LIQ_PAY_RESULT_URL",
 			"https://www.syodo.com.ua/ua/success", 1)
 	} else {
@@ -368,7 +485,9 @@ type successPaymentDTO struct {
 }
 
 // SuccessPayment confirm success payment in Syodo
-func (s *SyodoService) SuccessPayment(payment *telego.SuccessfulPayment, externalOrderID string) error {
+func (s *SyodoService) SuccessPayment(
+	ctx context.Context, payment *telego.SuccessfulPayment, externalOrderID string,
+) error {
 	successPayment := successPaymentDTO{
 		PayType:                 "telegram",
 		Status:                  "success",
@@ -384,12 +503,12 @@ func (s *SyodoService) SuccessPayment(payment *telego.SuccessfulPayment, externa
 	}
 
 	data := base64.StdEncoding.EncodeToString(dataJSON)
-	signature := sign(data, s.cfg.App.LiqPayPrivetKeyEnv)
+	signature := sign(data, s.config().App.LiqPayPrivetKeyEnv)
 	fullData := fmt.Sprintf("signature=%s&data=%s", signature, data)
 
-	s.log.Debugf("Payments callback data: %s", fullData)
+	logger.WithContext(s.log, ctx).Debugf("Payments callback data: %s", fullData)
 
-	if err = s.callURL("/payments/callback", fasthttp.MethodPost, fullData, nil); err != nil {
+	if err = s.callURL(ctx, "/payments/callback", fasthttp.MethodPost, fullData, nil); err != nil {
 		return fmt.Errorf("success payment API: %w", err)
 	}
 
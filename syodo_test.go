@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/mymmrac/syodo-telegram-bot/logger"
+)
+
+func TestPaymentToDTO(t *testing.T) {
+	tests := []struct {
+		name     string
+		order    OrderRequest
+		expected paymentDTO
+	}{
+		{name: "default", order: OrderRequest{}, expected: paymentDTO{PaymentMethod: paymentTypeOnline}},
+		{
+			name:     "online",
+			order:    OrderRequest{PaymentMethod: paymentMethodOnline},
+			expected: paymentDTO{PaymentMethod: paymentTypeOnline},
+		},
+		{
+			name:     "cash",
+			order:    OrderRequest{PaymentMethod: paymentMethodCash},
+			expected: paymentDTO{PaymentMethod: paymentTypeCash},
+		},
+		{
+			name:     "cash_change",
+			order:    OrderRequest{PaymentMethod: paymentMethodCash, ChangeFrom: 1000},
+			expected: paymentDTO{PaymentMethod: paymentTypeCash, RestFrom: "1000"},
+		},
+		{
+			name:     "card",
+			order:    OrderRequest{PaymentMethod: paymentMethodCard},
+			expected: paymentDTO{PaymentMethod: paymentTypeCard},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if actual := paymentToDTO(tt.order); actual != tt.expected {
+				t.Errorf("expected %+v, got %+v", tt.expected, actual)
+			}
+		})
+	}
+
+	if paymentTypeCash != "Готівка" || paymentTypeCard != "Картка кур'єру" || paymentTypeOnline != "Онлайн" {
+		t.Error("unexpected payment types sent to Syodo")
+	}
+}
+
+func TestRedactSyodoRequests(t *testing.T) {
+	point := pointDTO{Lat: 49.8397, Lng: 24.0297}
+	requests := map[string]any{
+		"price": priceRequest{
+			Order:           []orderDTO{{}},
+			DeliveryDetails: deliveryDTO{Type: "delivery", Point: point},
+		},
+		"checkout": checkoutRequest{
+			DeliveryDetails: deliveryDetailsDTO{Type: "delivery", Address: "Svobody 1", Point: point},
+		},
+	}
+
+	r := logger.NewRedactor()
+	for name, request := range requests {
+		data, err := json.Marshal(request)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		redacted := r.Redact(string(data))
+		for _, personal := range []string{"49.8397", "24.0297", "Svobody"} {
+			if strings.Contains(redacted, personal) {
+				t.Errorf("%s request: %q is not redacted: %s", name, personal, redacted)
+			}
+		}
+		if !strings.Contains(redacted, `"point":"***"`) {
+			t.Errorf("%s request: point is not redacted: %s", name, redacted)
+		}
+	}
+}
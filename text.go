@@ -4,56 +4,154 @@ import (
 	"bytes"
 	"fmt"
 	"html/template"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/BurntSushi/toml"
 )
 
-// TextData represents a map of text names and corresponding templates
-type TextData map[string]*template.Template
+// TextData represents text templates grouped by locales
+type TextData struct {
+	defaultLocale string
+	locales       map[string]map[string]*template.Template
+}
+
+// Temp return text in given locale with given data executing template, text from default locale is used if there is
+// no such text in given locale, if template of given locale failed to execute text from default locale is returned
+// along with an error
+func (t *TextData) Temp(locale, key string, data any) (string, error) {
+	text, err := t.execute(locale, key, data)
+	if err != nil && locale != t.defaultLocale {
+		if text, defaultErr := t.execute(t.defaultLocale, key, data); defaultErr == nil {
+			return text, err
+		}
+	}
+
+	return text, err
+}
+
+// Text return text in given locale executing template with no data
+func (t *TextData) Text(locale, key string) (string, error) {
+	return t.Temp(locale, key, nil)
+}
 
-// Temp return text with given data executing template, exits if not found or failed to execute
-func (t TextData) Temp(key string, data any) string {
-	temp, ok := t[key]
-	assert(ok, fmt.Sprintf("template with key %q not found", key))
+func (t *TextData) execute(locale, key string, data any) (string, error) {
+	temp, ok := t.locales[locale][key]
+	if !ok {
+		temp, ok = t.locales[t.defaultLocale][key]
+	}
+	if !ok {
+		return "", fmt.Errorf("template with key %q not found", key)
+	}
 
 	buf := &bytes.Buffer{}
-	err := temp.Execute(buf, data)
-	assert(err == nil, fmt.Errorf("execute template with key %q and data %+v, error: %w", key, data, err))
+	if err := temp.Execute(buf, data); err != nil {
+		return "", fmt.Errorf("execute template with key %q in locale %q, error: %w", key, locale, err)
+	}
+
+	return buf.String(), nil
+}
+
+// DefaultLocale returns default locale
+func (t *TextData) DefaultLocale() string {
+	return t.defaultLocale
+}
+
+// Locales returns sorted list of all locales
+func (t *TextData) Locales() []string {
+	locales := make([]string, 0, len(t.locales))
+	for locale := range t.locales {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+
+	return locales
+}
+
+// Locale returns supported locale matching IETF language tag (e.g. "en" or "en-US"), default locale is returned if
+// there is no matching one
+func (t *TextData) Locale(languageCode string) string {
+	languageCode = strings.ToLower(languageCode)
+	if _, ok := t.locales[languageCode]; ok {
+		return languageCode
+	}
+
+	language, _, _ := strings.Cut(languageCode, "-")
+	if _, ok := t.locales[language]; ok {
+		return language
+	}
 
-	return buf.String()
+	return t.defaultLocale
 }
 
-// Text return text executing template with no data, exits if not found or failed to execute
-func (t TextData) Text(key string) string {
-	return t.Temp(key, nil)
+// LoadTextData loads text templates from specified file and validates them, timezone and catalog are used by template
+// functions
+func LoadTextData(filename string, timezone *time.Location, catalog *Catalog) (*TextData, error) {
+	textData, err := parseTextData(filename, timezone, catalog)
+	if err != nil {
+		return nil, err
+	}
+
+	if problems := textData.Validate(); len(problems) != 0 {
+		messages := make([]string, len(problems))
+		for i, problem := range problems {
+			messages[i] = problem.Error()
+		}
+
+		return nil, fmt.Errorf("validate text data: %s", strings.Join(messages, "; "))
+	}
+
+	return textData, nil
 }
 
-const priceMultiplier = 100.0
+// Validate checks text data against embedded text schema executing each template with sample data, used both on load
+// and in check-text mode
+func (t *TextData) Validate() []error {
+	schema, err := parseTextSchema(textSchemaData)
+	if err != nil {
+		return []error{err}
+	}
+
+	return t.Check(schema)
+}
 
-// LoadTextData loads text templates from specified file
-func LoadTextData(filename string) (TextData, error) {
-	var textValues map[string]string
+// parseTextData parses text templates from specified file
+func parseTextData(filename string, timezone *time.Location, catalog *Catalog) (*TextData, error) {
+	var textFile struct {
+		DefaultLocale string
+		Locales       map[string]map[string]string
+	}
 
-	_, err := toml.DecodeFile(filename, &textValues)
+	_, err := toml.DecodeFile(filename, &textFile)
 	if err != nil {
 		return nil, fmt.Errorf("decode text data: %w", err)
 	}
 
-	textData := make(map[string]*template.Template, len(textValues))
+	if _, ok := textFile.Locales[textFile.DefaultLocale]; !ok {
+		return nil, fmt.Errorf("no texts for default locale %q", textFile.DefaultLocale)
+	}
+
+	fm := textFuncs(timezone, catalog)
 
-	fm := template.FuncMap{
-		"toPrice": func(amount int) string {
-			return fmt.Sprintf("%.2f", float64(amount)/priceMultiplier)
-		},
+	textData := &TextData{
+		defaultLocale: textFile.DefaultLocale,
+		locales:       make(map[string]map[string]*template.Template, len(textFile.Locales)),
 	}
 
-	for key, value := range textValues {
-		transformedValue := strings.TrimSpace(strings.ReplaceAll(value, "|\n", ""))
-		textData[key], err = template.New(key).Funcs(fm).Parse(transformedValue)
-		if err != nil {
-			return nil, fmt.Errorf("parsing text data of %q with value %q, error: %w", key, transformedValue, err)
+	for locale, textValues := range textFile.Locales {
+		templates := make(map[string]*template.Template, len(textValues))
+
+		for key, value := range textValues {
+			transformedValue := strings.TrimSpace(strings.ReplaceAll(value, "|\n", ""))
+			templates[key], err = template.New(key).Funcs(fm).Parse(transformedValue)
+			if err != nil {
+				return nil, fmt.Errorf("parsing text data of %q in locale %q with value %q, error: %w",
+					key, locale, transformedValue, err)
+			}
 		}
+
+		textData.locales[locale] = templates
 	}
 
 	return textData, nil
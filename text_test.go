@@ -2,61 +2,145 @@ package main
 
 import (
 	"testing"
+	"time"
 
 	"github.com/mymmrac/telego"
 )
 
+func loadTestTextData(t *testing.T) *TextData {
+	t.Helper()
+
+	catalog, err := LoadCatalog("catalog.toml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := LoadTextData("text.toml", time.UTC, catalog)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return data
+}
+
 func TestTextData(t *testing.T) {
-	data, err := LoadTextData("text.toml")
+	data := loadTestTextData(t)
+
+	if locales := data.Locales(); len(locales) < 2 {
+		t.Fatalf("expected multiple locales, got: %v", locales)
+	}
+
+	schema, err := parseTextSchema(textSchemaData)
 	if err != nil {
-		t.Error(err)
-	}
-
-	keys := []string{
-		"startDescription",
-		"helpDescription",
-		"siteButtonText",
-		"instagramButtonText",
-		"facebookButtonText",
-		"siteURL",
-		"instagramURL",
-		"facebookURL",
-		"menuButton",
-		"orderNotFoundError",
-		"orderCheckoutError",
-		"orderDescription",
-		"successPaymentOrderNotFoundError",
-		"successPaymentOrderFailedError",
-		"unknownMessage",
-	}
-
-	templates := []struct {
-		key  string
-		data any
+		t.Fatal(err)
+	}
+
+	for _, problem := range data.Check(schema) {
+		t.Error(problem)
+	}
+
+	for _, locale := range data.Locales() {
+		for key, declaration := range schema {
+			if _, err = data.Temp(locale, key, textDataSamples[declaration.Data]); err != nil {
+				t.Error(err)
+			}
+		}
+	}
+}
+
+func TestTextDataLocale(t *testing.T) {
+	data := loadTestTextData(t)
+
+	tests := map[string]string{
+		"":      "uk",
+		"uk":    "uk",
+		"en":    "en",
+		"en-US": "en",
+		"EN-gb": "en",
+		"de":    "uk",
+	}
+
+	for languageCode, expected := range tests {
+		if locale := data.Locale(languageCode); locale != expected {
+			t.Errorf("expected locale %q for %q, got %q", expected, languageCode, locale)
+		}
+	}
+}
+
+func TestTextDataFallback(t *testing.T) {
+	data := loadTestTextData(t)
+
+	if _, err := data.Text("uk", "unknownKey"); err == nil {
+		t.Error("expected error for unknown key")
+	}
+
+	if text, err := data.Temp("en", "start", telego.Message{}); err == nil || text != "" {
+		t.Errorf("expected error for bad data, got: %q", text)
+	}
+
+	text, err := data.Text("en", "siteURL")
+	if err != nil || text == "" {
+		t.Errorf("expected text from default locale, got: %q, %v", text, err)
+	}
+}
+
+func TestValidateTelegramHTML(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		valid bool
 	}{
-		{
-			key: "start",
-			data: telego.Message{
-				From: &telego.User{},
-			},
-		},
-		{
-			key: "help",
-			data: telego.Message{
-				From: &telego.User{},
-			},
-		},
-		{
-			key:  "successPayment",
-			data: OrderDetails{},
-		},
-	}
-
-	for _, text := range keys {
-		_ = data.Text(text)
-	}
-
-	for _, temp := range templates {
-		_ = data.Temp(temp.key, temp.data)
+		{name: "plain", text: "Hello", valid: true},
+		{name: "tags", text: "<b>Hi</b> <i><u>there</u></i>", valid: true},
+		{name: "link", text: `<a href="https://syodo.com.ua">here</a>`, valid: true},
+		{name: "entities", text: "&lt;3 &amp; &#39;", valid: true},
+		{name: "unknown_tag", text: "<div>Hi</div>"},
+		{name: "unknown_attribute", text: `<b style="color: red">Hi</b>`},
+		{name: "unclosed", text: "<b>Hi"},
+		{name: "bad_nesting", text: "<b><i>Hi</b></i>"},
+		{name: "unescaped_ampersand", text: "Salt & pepper"},
+		{name: "unescaped_bracket", text: "1 < 2"},
+		{name: "unknown_entity", text: "&nbsp;"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateTelegramHTML(tt.text)
+			if tt.valid && err != nil {
+				t.Errorf("expected valid, got: %s", err)
+			}
+			if !tt.valid && err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestTextFuncs(t *testing.T) {
+	plurals := map[int]string{1: "суша", 2: "суші", 5: "суш", 11: "суш", 21: "суша", 22: "суші", 112: "суш"}
+	for count, expected := range plurals {
+		if actual := pluralUK(count, "суша", "суші", "суш"); actual != expected {
+			t.Errorf("plural of %d: expected %q, got %q", count, expected, actual)
+		}
+	}
+
+	if actual := truncate(5, "Філадельфія"); actual != "Філад…" {
+		t.Errorf("unexpected truncated text: %q", actual)
+	}
+	if actual := truncate(20, "Філадельфія"); actual != "Філадельфія" {
+		t.Errorf("unexpected not truncated text: %q", actual)
+	}
+}
+
+func TestTextDataValidateRequired(t *testing.T) {
+	data := loadTestTextData(t)
+
+	if problems := data.Validate(); len(problems) != 0 {
+		t.Fatalf("unexpected problems: %v", problems)
+	}
+
+	delete(data.locales[data.DefaultLocale()], "closed")
+	if problems := data.Validate(); len(problems) == 0 {
+		t.Error("expected missing closed text to be reported")
 	}
 }
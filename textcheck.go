@@ -0,0 +1,271 @@
+package main
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/go-playground/validator/v10"
+	"github.com/mymmrac/telego"
+)
+
+//go:embed text.schema.toml
+var textSchemaData string
+
+// TextDeclaration represents declaration of text: type of its data, if it's required in default locale and if it's
+// sent using HTML formatting
+type TextDeclaration struct {
+	Data     string `validate:"omitempty,oneof=Message ClosedInfo OrderDetails string int time"`
+	Required bool   `validate:"-"`
+	HTML     bool   `validate:"-"`
+}
+
+// TextSchema represents a map of text names and corresponding declarations
+type TextSchema map[string]TextDeclaration
+
+// parseTextSchema parses and validates text schema
+func parseTextSchema(data string) (TextSchema, error) {
+	var schema TextSchema
+	if _, err := toml.Decode(data, &schema); err != nil {
+		return nil, fmt.Errorf("decode text schema: %w", err)
+	}
+
+	validate := validator.New()
+	for key, declaration := range schema {
+		if err := validate.Struct(declaration); err != nil {
+			return nil, fmt.Errorf("text schema of %q validation: %w", key, err)
+		}
+	}
+
+	return schema, nil
+}
+
+// textDataSamples represents sample data for each data type declared in text schema
+var textDataSamples = map[string]any{
+	"": nil,
+	"Message": telego.Message{
+		From: &telego.User{FirstName: "Taras"},
+	},
+	"ClosedInfo": ClosedInfo{
+		Reason:      "Reason",
+		NextOpening: time.Date(2023, 3, 18, 10, 0, 0, 0, time.UTC),
+	},
+	"OrderDetails": OrderDetails{
+		OrderID: "000001",
+		Request: OrderRequest{
+			Products: []OrderProduct{
+				{ID: "1", Title: "Філадельфія", Price: 29900, Amount: 2, CategoryID: "7"},
+			},
+			Name:          "Taras",
+			Phone:         "+380671234567",
+			PaymentMethod: paymentMethodCash,
+			ChangeFrom:    500,
+		},
+		OrderURL:    "https://syodo.com.ua",
+		ScheduledAt: time.Date(2023, 3, 18, 18, 0, 0, 0, time.UTC),
+		TotalAmount: 499.5,
+	},
+	"string": "text",
+	"int":    1,
+	"time":   time.Date(2023, 3, 18, 18, 0, 0, 0, time.UTC),
+}
+
+// Check validates text data against text schema, all found problems are returned
+//
+//nolint:gocognit,cyclop
+func (t *TextData) Check(schema TextSchema) []error {
+	var problems []error
+
+	for _, key := range sortedKeys(schema) {
+		if _, ok := t.locales[t.defaultLocale][key]; !ok && schema[key].Required {
+			problems = append(problems, fmt.Errorf("missing text %q in default locale %q", key, t.defaultLocale))
+		}
+	}
+
+	for _, locale := range t.Locales() {
+		for _, key := range sortedKeys(t.locales[locale]) {
+			declaration, ok := schema[key]
+			if !ok {
+				problems = append(problems, fmt.Errorf("unknown text %q in locale %q", key, locale))
+				continue
+			}
+
+			if _, ok = t.locales[t.defaultLocale][key]; !ok {
+				problems = append(problems, fmt.Errorf("text %q of locale %q not found in default locale", key, locale))
+			}
+
+			text, err := t.execute(locale, key, textDataSamples[declaration.Data])
+			if err != nil {
+				problems = append(problems, err)
+				continue
+			}
+
+			if declaration.HTML {
+				if err = validateTelegramHTML(text); err != nil {
+					problems = append(problems, fmt.Errorf("text %q of locale %q: %w", key, locale, err))
+				}
+			}
+		}
+	}
+
+	return problems
+}
+
+// MissingTranslations returns texts of default locale that are not present in other locales grouped by locale
+func (t *TextData) MissingTranslations() map[string][]string {
+	missing := make(map[string][]string)
+	for _, locale := range t.Locales() {
+		for _, key := range sortedKeys(t.locales[t.defaultLocale]) {
+			if _, ok := t.locales[locale][key]; !ok {
+				missing[locale] = append(missing[locale], key)
+			}
+		}
+	}
+
+	return missing
+}
+
+// telegramHTMLTags represents tags and their attributes supported by Telegram HTML formatting
+var telegramHTMLTags = map[string][]string{
+	"b":          nil,
+	"strong":     nil,
+	"i":          nil,
+	"em":         nil,
+	"u":          nil,
+	"ins":        nil,
+	"s":          nil,
+	"strike":     nil,
+	"del":        nil,
+	"span":       {"class"},
+	"tg-spoiler": nil,
+	"a":          {"href"},
+	"tg-emoji":   {"emoji-id"},
+	"code":       {"class"},
+	"pre":        nil,
+}
+
+var (
+	htmlTagPattern       = regexp.MustCompile(`<(/?)([^\s>/]*)([^>]*)>`)
+	htmlAttributePattern = regexp.MustCompile(`([a-zA-Z-]+)\s*=\s*"[^"]*"`)
+	htmlEntityPattern    = regexp.MustCompile(`&([a-zA-Z]+|#[0-9]+|#x[0-9a-fA-F]+);`)
+)
+
+// telegramHTMLEntities represents named HTML entities supported by Telegram
+var telegramHTMLEntities = map[string]struct{}{
+	"lt":   {},
+	"gt":   {},
+	"amp":  {},
+	"quot": {},
+}
+
+// validateTelegramHTML checks that text uses only tags, attributes and entities supported by Telegram and all tags are
+// properly nested
+//
+//nolint:cyclop
+func validateTelegramHTML(text string) error {
+	var openTags []string
+
+	for _, match := range htmlTagPattern.FindAllStringSubmatch(text, -1) {
+		closing, name, attributes := match[1] == "/", strings.ToLower(match[2]), strings.TrimSpace(match[3])
+
+		allowedAttributes, ok := telegramHTMLTags[name]
+		if !ok {
+			return fmt.Errorf("unsupported tag %q", match[0])
+		}
+
+		if closing {
+			if len(openTags) == 0 || openTags[len(openTags)-1] != name {
+				return fmt.Errorf("unexpected closing tag %q", match[0])
+			}
+			openTags = openTags[:len(openTags)-1]
+			continue
+		}
+
+		for _, attribute := range htmlAttributePattern.FindAllStringSubmatch(attributes, -1) {
+			if !containsFold(allowedAttributes, attribute[1]) {
+				return fmt.Errorf("unsupported attribute %q of tag %q", attribute[1], name)
+			}
+		}
+		if strings.TrimSpace(htmlAttributePattern.ReplaceAllString(attributes, "")) != "" {
+			return fmt.Errorf("malformed attributes of tag %q", match[0])
+		}
+
+		openTags = append(openTags, name)
+	}
+
+	if len(openTags) != 0 {
+		return fmt.Errorf("unclosed tags: %s", strings.Join(openTags, ", "))
+	}
+
+	stripped := htmlTagPattern.ReplaceAllString(text, "")
+	if strings.ContainsAny(stripped, "<>") {
+		return errors.New("unescaped < or >")
+	}
+
+	for _, entity := range htmlEntityPattern.FindAllStringSubmatch(stripped, -1) {
+		if _, ok := telegramHTMLEntities[entity[1]]; !ok && !strings.HasPrefix(entity[1], "#") {
+			return fmt.Errorf("unsupported entity %q", entity[0])
+		}
+	}
+	if strings.Count(stripped, "&") != len(htmlEntityPattern.FindAllString(stripped, -1)) {
+		return errors.New("unescaped &")
+	}
+
+	return nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// checkText checks text data file using catalog file and prints all found problems, returns false if there are any
+// problems
+func checkText(filename, catalogFilename string) bool {
+	timezone, err := time.LoadLocation(timezoneName)
+	if err != nil {
+		fmt.Println("ERROR:", err)
+		return false
+	}
+
+	catalog, err := LoadCatalog(catalogFilename)
+	if err != nil {
+		fmt.Println("ERROR:", err)
+		return false
+	}
+
+	textData, err := parseTextData(filename, timezone, catalog)
+	if err != nil {
+		fmt.Println("ERROR:", err)
+		return false
+	}
+
+	missing := textData.MissingTranslations()
+	for _, locale := range sortedKeys(missing) {
+		fmt.Printf("WARN: locale %q has no translation for (default locale used): %s\n",
+			locale, strings.Join(missing[locale], ", "))
+	}
+
+	problems := textData.Validate()
+	for _, problem := range problems {
+		fmt.Println("ERROR:", problem)
+	}
+
+	if len(problems) != 0 {
+		fmt.Printf("Found %d problem(s) in %s\n", len(problems), filename)
+		return false
+	}
+
+	fmt.Printf("No problems found in %s\n", filename)
+	return true
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/mymmrac/syodo-telegram-bot/logger"
+)
+
+const (
+	priceMultiplier = 100.0
+
+	textDateLayout     = "02.01.2006"
+	textTimeLayout     = "15:04"
+	textDateTimeLayout = textDateLayout + " " + textTimeLayout
+
+	truncateEllipsis = "…"
+)
+
+// textFuncs returns functions available in text templates, timezone is used for date & time formatting and catalog
+// for category emoji
+func textFuncs(timezone *time.Location, catalog *Catalog) template.FuncMap {
+	return template.FuncMap{
+		"toPrice": toPrice,
+		"plural":  pluralUK,
+		"formatDate": func(t time.Time) string {
+			return t.In(timezone).Format(textDateLayout)
+		},
+		"formatTime": func(t time.Time) string {
+			return t.In(timezone).Format(textTimeLayout)
+		},
+		"formatDateTime": func(t time.Time) string {
+			return t.In(timezone).Format(textDateTimeLayout)
+		},
+		"maskPhone": logger.MaskPhone,
+		"truncate":  truncate,
+		"emoji":     catalog.Emoji,
+		"items": func(products []OrderProduct) string {
+			return itemsList(catalog, products)
+		},
+	}
+}
+
+// toPrice formats amount in kopecks as price
+func toPrice(amount int) string {
+	return fmt.Sprintf("%.2f", float64(amount)/priceMultiplier)
+}
+
+// pluralUK returns Ukrainian plural form of a noun for given count, e.g. 1 суша, 2 суші, 5 суш
+//
+//nolint:gomnd
+func pluralUK(count int, one, few, many string) string {
+	if count < 0 {
+		count = -count
+	}
+
+	switch lastTwo, last := count%100, count%10; {
+	case last == 1 && lastTwo != 11:
+		return one
+	case last >= 2 && last <= 4 && (lastTwo < 12 || lastTwo > 14):
+		return few
+	default:
+		return many
+	}
+}
+
+// truncate cuts text to specified number of characters adding ellipsis, text is cut before escaping, so HTML
+// entities are never broken
+func truncate(length int, text string) string {
+	if length <= 0 || utf8.RuneCountInString(text) <= length {
+		return text
+	}
+
+	runes := []rune(text)
+	return strings.TrimSpace(string(runes[:length])) + truncateEllipsis
+}
+
+// itemsList renders list of products with their emoji, amount, title and price each on a separate line
+func itemsList(catalog *Catalog, products []OrderProduct) string {
+	lines := make([]string, 0, len(products))
+	for _, p := range products {
+		lines = append(lines, fmt.Sprintf("%s %d ✕ %s — %s", catalog.Emoji(p.CategoryID), p.Amount, p.Title,
+			toPrice(p.Amount*p.Price)))
+	}
+
+	return strings.Join(lines, "\n")
+}
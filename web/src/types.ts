@@ -74,3 +74,37 @@ export type ProductListItems = ProductListItem[]
 export function isProduct(item: ProductListItem): item is Product {
     return (<Product> item).price !== undefined
 }
+
+// Response to order request paid on delivery, invoice is not created
+export type OfflineOrderResponse = {
+    orderID: string
+}
+
+// Error details returned by bot API with non-200 status (e.g. 503 when closed, 429 when rate limited)
+export type OrderError = {
+    error: "closed" | "rate_limited"
+    reason?: string
+    nextOpening?: string
+    retryAfter?: number
+}
+
+export function orderErrorToText(orderError: OrderError): string {
+    switch (orderError.error) {
+        case "closed": {
+            let text = "Вибачте, зараз ми не приймаємо замовлення"
+            if (orderError.reason) {
+                text += "\n\n" + orderError.reason
+            }
+            if (orderError.nextOpening) {
+                text += "\n\nВідкриємось: " + new Date(orderError.nextOpening).toLocaleString("uk-UA", {
+                    timeZone: "Europe/Kiev",
+                    dateStyle: "medium",
+                    timeStyle: "short",
+                })
+            }
+            return text
+        }
+        case "rate_limited":
+            return `Забагато спроб замовлення, спробуйте через ${ orderError.retryAfter ?? 1 } с`
+    }
+}